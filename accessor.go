@@ -0,0 +1,116 @@
+package goconfig
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrSectionNotFound is returned from Section when no section or
+// http client has been loaded with the name requested.
+var ErrSectionNotFound = errors.New("section not found")
+
+// Section will return the section loaded under name as type T.
+//
+// Custom sections added with WithSection, http clients added with WithHTTPClient and
+// the built in sections (server, env, log, db, redis, swagger, instrumentation) can
+// all be retrieved. Sections stored as pointers can be returned as a pointer or value:
+//
+//	payments, err := goconfig.Section[*PaymentsConfig](cfg, "payments")
+//	client, err := goconfig.Section[goconfig.HTTPClientConfig](cfg, "my-service")
+//
+// If the section isn't found an error wrapping ErrSectionNotFound is returned
+// naming the With* call that is missing.
+func Section[T any](cfg *Config, name string) (T, error) {
+	var zero T
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	val, ok := cfg.section(name)
+	if !ok {
+		return zero, fmt.Errorf("%w: %q, did you forget to call %s?", ErrSectionNotFound, name, sectionHint(name, typ))
+	}
+	if t, ok := val.(T); ok {
+		return t, nil
+	}
+	if rv := reflect.ValueOf(val); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if t, ok := rv.Elem().Interface().(T); ok {
+			return t, nil
+		}
+	}
+	return zero, fmt.Errorf("section %q is of type %T, not %s", name, val, typ)
+}
+
+// MustSection calls Section and panics if an error is returned.
+func MustSection[T any](cfg *Config, name string) T {
+	t, err := Section[T](cfg, name)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// section will find a loaded section by name, custom sections
+// take precedence over http clients and then built in sections.
+func (c *Config) section(name string) (interface{}, bool) {
+	if s, ok := c.sections[name]; ok {
+		return s, true
+	}
+	if h := c.CustomHTTPClient(name); h != nil {
+		return h, true
+	}
+	var s interface{}
+	switch name {
+	case "server":
+		s = c.Server
+	case "env":
+		s = c.Deployment
+	case "log":
+		s = c.Logging
+	case "db":
+		s = c.Db
+	case "redis":
+		s = c.Redis
+	case "swagger":
+		s = c.Swagger
+	case "instrumentation":
+		s = c.Instrumentation
+	default:
+		return nil, false
+	}
+	// typed nil pointers are not nil interfaces, check the value.
+	if reflect.ValueOf(s).IsNil() {
+		return nil, false
+	}
+	return s, true
+}
+
+// sectionHint returns the loader call that adds the section name.
+func sectionHint(name string, typ reflect.Type) string {
+	switch name {
+	case "server":
+		return "WithServer()"
+	case "env":
+		return "WithEnvironment(appname)"
+	case "log":
+		return "WithLog()"
+	case "db":
+		return "WithDb()"
+	case "redis":
+		return "WithRedis()"
+	case "swagger":
+		return "WithSwagger()"
+	case "instrumentation":
+		return "WithInstrumentation()"
+	}
+	if typ == reflect.TypeOf(HTTPClientConfig{}) || typ == reflect.TypeOf(&HTTPClientConfig{}) {
+		return fmt.Sprintf("WithHTTPClient(%q)", name)
+	}
+	return fmt.Sprintf("WithSection(%q, &%s{})", name, derefType(typ).Name())
+}
+
+// derefType returns the element type of typ if it is a pointer.
+func derefType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
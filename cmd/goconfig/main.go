@@ -0,0 +1,314 @@
+// Command goconfig loads configuration the same way an app using goconfig
+// does, from config files and the environment, so it can be checked
+// before, or during, a deployment.
+//
+// Usage:
+//
+//	goconfig validate [flags]
+//	goconfig print [flags]
+//	goconfig explain [flags] KEY
+//	goconfig encrypt [flags] [VALUE]
+//	goconfig decrypt [flags] [VALUE]
+//	goconfig keygen
+//
+// Flags choose the config file and which sections to load, for example:
+//
+//	goconfig validate -app my-app -file config.prod.yaml -server -db -client payments
+//
+// Values encrypted with encrypt can be committed to config files, if VALUE
+// isn't given it is read from stdin so it isn't kept in your shell history:
+//
+//	goconfig encrypt -key-file prod.key < dsn.txt
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/theflyingcodr/goconfig"
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+const usage = `goconfig loads configuration from config files and the environment.
+
+Usage:
+
+	goconfig validate [flags]      exit non-zero if the config is invalid
+	goconfig print [flags]         print the effective config, secrets are redacted
+	goconfig explain [flags] KEY   print each source of KEY in order of precedence
+	goconfig encrypt [flags] VALUE encrypt VALUE, or stdin, for use in a config file
+	goconfig decrypt [flags] VALUE decrypt an enc: VALUE, or stdin
+	goconfig keygen                print a new encryption key
+
+Run 'goconfig <command> -h' for the flags of a command.
+`
+
+// clientNames collects the repeated -client flag.
+type clientNames []string
+
+// String implements flag.Value.
+func (c *clientNames) String() string {
+	return strings.Join(*c, ",")
+}
+
+// Set implements flag.Value.
+func (c *clientNames) Set(name string) error {
+	*c = append(*c, name)
+	return nil
+}
+
+// loaderFlags are the flags shared by each command.
+type loaderFlags struct {
+	app             string
+	file            string
+	envPrefix       string
+	keyFile         string
+	keyEnv          string
+	strict          bool
+	server          bool
+	env             bool
+	log             bool
+	db              bool
+	redis           bool
+	swagger         bool
+	instrumentation bool
+	clients         clientNames
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command in args, returning the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "validate", "print", "explain":
+	case "encrypt", "decrypt":
+		return crypt(cmd, args, stdin, stdout, stderr)
+	case "keygen":
+		return keygen(stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", cmd, usage)
+		return 2
+	}
+	fs := flag.NewFlagSet("goconfig "+cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	lf := &loaderFlags{}
+	fs.StringVar(&lf.app, "app", "app", "app name, used for the config search paths /etc/<app>/ and $HOME/.<app>")
+	fs.StringVar(&lf.file, "file", "", "config file to read, rather than searching the default paths")
+	fs.StringVar(&lf.envPrefix, "env-prefix", "", "env var prefix, such as MY_APP")
+	fs.StringVar(&lf.keyFile, "key-file", "", "file containing the key used to decrypt enc: values")
+	fs.StringVar(&lf.keyEnv, "key-env", goconfig.DefaultKeyEnv, "env var containing the key used to decrypt enc: values")
+	fs.BoolVar(&lf.strict, "strict", false, "report config keys and prefixed env vars that aren't used")
+	fs.BoolVar(&lf.server, "server", false, "load the server section")
+	fs.BoolVar(&lf.env, "env", false, "load the deployment environment section")
+	fs.BoolVar(&lf.log, "log", false, "load the log section")
+	fs.BoolVar(&lf.db, "db", false, "load the db section")
+	fs.BoolVar(&lf.redis, "redis", false, "load the redis section")
+	fs.BoolVar(&lf.swagger, "swagger", false, "load the swagger section")
+	fs.BoolVar(&lf.instrumentation, "instrumentation", false, "load the instrumentation section")
+	fs.Var(&lf.clients, "client", "load the http client called `name`, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	loader := lf.loader()
+	switch cmd {
+	case "validate":
+		return validateConfig(loader, stdout, stderr)
+	case "print":
+		return printConfig(loader, stdout, stderr)
+	default:
+		if fs.NArg() != 1 {
+			fmt.Fprintln(stderr, "explain requires a single KEY, such as server.port")
+			return 2
+		}
+		return explainKey(loader, fs.Arg(0), stdout, stderr)
+	}
+}
+
+// loader returns a ViperConfig with each section enabled by the flags loaded.
+func (lf *loaderFlags) loader() *goconfig.ViperConfig {
+	var opts []goconfig.ViperOption
+	if lf.file != "" {
+		opts = append(opts, goconfig.WithConfigFile(lf.file))
+	}
+	if lf.envPrefix != "" {
+		opts = append(opts, goconfig.WithEnvPrefix(lf.envPrefix))
+	}
+	if lf.keyFile != "" {
+		opts = append(opts, goconfig.WithDecryptionKeyFile(lf.keyFile))
+	}
+	opts = append(opts, goconfig.WithDecryptionKeyEnv(lf.keyEnv))
+	if lf.strict {
+		opts = append(opts, goconfig.WithStrict(goconfig.StrictFail))
+	}
+	loader := goconfig.NewViperConfig(lf.app, opts...)
+	if lf.server {
+		loader.WithServer()
+	}
+	if lf.env {
+		loader.WithEnvironment(lf.app)
+	}
+	if lf.log {
+		loader.WithLog()
+	}
+	if lf.db {
+		loader.WithDb()
+	}
+	if lf.redis {
+		loader.WithRedis()
+	}
+	if lf.swagger {
+		loader.WithSwagger()
+	}
+	if lf.instrumentation {
+		loader.WithInstrumentation()
+	}
+	for _, name := range lf.clients {
+		loader.WithHTTPClient(name)
+	}
+	return loader
+}
+
+// validateConfig loads the config, printing any errors.
+func validateConfig(loader *goconfig.ViperConfig, stdout, stderr io.Writer) int {
+	if _, err := loader.LoadE(); err != nil {
+		printErr(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "config is valid")
+	return 0
+}
+
+// printConfig outputs the value of each key loaded, followed by any errors.
+func printConfig(loader *goconfig.ViperConfig, stdout, stderr io.Writer) int {
+	_, err := loader.LoadE()
+	values := loader.Values()
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		val := values[k]
+		if val == nil {
+			val = ""
+		}
+		fmt.Fprintf(stdout, "%s: %v\n", k, val)
+	}
+	if err != nil {
+		printErr(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// explainKey outputs each source of key, the first is the value in use.
+func explainKey(loader *goconfig.ViperConfig, key string, stdout, stderr io.Writer) int {
+	sources := loader.Explain(key)
+	if len(sources) == 0 {
+		fmt.Fprintf(stderr, "no value found for %s\n", key)
+		return 1
+	}
+	for i, s := range sources {
+		s.Value = loader.RedactValue(key, s.Value)
+		marker := " "
+		if i == 0 {
+			marker = "*"
+		}
+		fmt.Fprintf(stdout, "%s %s\n", marker, s)
+	}
+	return 0
+}
+
+// crypt encrypts or decrypts the value given as an argument, or read from stdin.
+func crypt(cmd string, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("goconfig "+cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keyFile := fs.String("key-file", "", "file containing the key")
+	keyEnv := fs.String("key-env", goconfig.DefaultKeyEnv, "env var containing the key, used if -key-file isn't set")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	key, err := goconfig.ReadKey(*keyFile, *keyEnv)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	var value string
+	switch fs.NArg() {
+	case 0:
+		bb, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		value = strings.TrimRight(string(bb), "\r\n")
+	case 1:
+		value = fs.Arg(0)
+	default:
+		fmt.Fprintf(stderr, "%s takes a single VALUE\n", cmd)
+		return 2
+	}
+	var out string
+	if cmd == "encrypt" {
+		out, err = goconfig.Encrypt(key, value)
+	} else {
+		out, err = goconfig.Decrypt(key, strings.TrimSpace(value))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to %s: %s\n", cmd, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, out)
+	return 0
+}
+
+// keygen prints a new key for use with encrypt.
+func keygen(stdout, stderr io.Writer) int {
+	key, err := goconfig.GenerateKey()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, key)
+	return 0
+}
+
+// printErr writes err to w, validation errors are written one key per line.
+func printErr(w io.Writer, err error) {
+	var errs validator.ErrValidation
+	if !errors.As(err, &errs) {
+		fmt.Fprintln(w, err)
+		return
+	}
+	keys := make([]string, 0, len(errs))
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, msg := range errs[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, msg)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `server:
+  port: 9000
+db:
+  type: sqlite
+  dsn: secret-dsn
+`
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		args      []string
+		env       map[string]string
+		expCode   int
+		expStdout string
+		expStderr string
+	}{
+		"no command": {
+			expCode:   2,
+			expStderr: "Usage:",
+		},
+		"unknown command": {
+			args:      []string{"lint"},
+			expCode:   2,
+			expStderr: `unknown command "lint"`,
+		},
+		"validate valid": {
+			args:      []string{"validate", "-file", path, "-server", "-db"},
+			expStdout: "config is valid\n",
+		},
+		"validate invalid": {
+			args:    []string{"validate", "-file", path, "-server", "-client", "payments"},
+			env:     map[string]string{"SERVER_PORT": "70000"},
+			expCode: 1,
+			expStderr: "payments.client.host: value is required, set env var PAYMENTS_CLIENT_HOST or key " +
+				"payments.client.host in a config file\n" +
+				"server.port: port 70000 must be between 1 and 65535 (env var SERVER_PORT, key server.port, source env SERVER_PORT)\n",
+		},
+		"validate bad flag": {
+			args:      []string{"validate", "-nope"},
+			expCode:   2,
+			expStderr: "flag provided but not defined: -nope",
+		},
+		"print redacts secrets": {
+			args:      []string{"print", "-file", path, "-db"},
+			expStdout: "db.dsn: [REDACTED]\ndb.migrate: false\ndb.schema.path: \ndb.type: sqlite\n",
+		},
+		"print invalid": {
+			args:      []string{"print", "-file", path, "-db"},
+			env:       map[string]string{"DB_TYPE": "cassandra"},
+			expCode:   1,
+			expStdout: "db.dsn: [REDACTED]\ndb.migrate: false\ndb.schema.path: \ndb.type: cassandra\n",
+			expStderr: "db.type:",
+		},
+		"explain": {
+			args:      []string{"explain", "-file", path, "-server", "server.port"},
+			env:       map[string]string{"SERVER_PORT": "9100"},
+			expStdout: "* env SERVER_PORT: 9100\n  file " + path + ": 9000\n  default: 8080\n",
+		},
+		"explain redacts secrets": {
+			args:      []string{"explain", "-file", path, "-db", "db.dsn"},
+			expStdout: "* file " + path + ": [REDACTED]\n",
+		},
+		"explain no value": {
+			args:      []string{"explain", "-file", path, "-server", "server.tls.cert"},
+			expCode:   1,
+			expStderr: "no value found for server.tls.cert\n",
+		},
+		"explain without key": {
+			args:      []string{"explain", "-file", path},
+			expCode:   2,
+			expStderr: "explain requires a single KEY",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			var stdout, stderr bytes.Buffer
+			code := run(test.args, strings.NewReader(""), &stdout, &stderr)
+			if code != test.expCode {
+				t.Fatalf("expected exit code %d, got %d, stderr: %s", test.expCode, code, stderr.String())
+			}
+			if stdout.String() != test.expStdout {
+				t.Fatalf("expected stdout %q, got %q", test.expStdout, stdout.String())
+			}
+			if test.expStderr == "" && stderr.Len() > 0 {
+				t.Fatalf("unexpected stderr %q", stderr.String())
+			}
+			if !strings.Contains(stderr.String(), test.expStderr) {
+				t.Fatalf("expected stderr to contain %q, got %q", test.expStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_EncryptDecrypt(t *testing.T) {
+	var key bytes.Buffer
+	if code := run([]string{"keygen"}, nil, &key, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("keygen exited %d", code)
+	}
+	t.Setenv("TEST_KEY", strings.TrimSpace(key.String()))
+	var enc, stderr bytes.Buffer
+	if code := run([]string{"encrypt", "-key-env", "TEST_KEY"}, strings.NewReader("secret-dsn\n"), &enc, &stderr); code != 0 {
+		t.Fatalf("encrypt exited %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(enc.String(), "enc:") {
+		t.Fatalf("expected enc: value, got %q", enc.String())
+	}
+	var dec bytes.Buffer
+	if code := run([]string{"decrypt", "-key-env", "TEST_KEY", strings.TrimSpace(enc.String())}, nil, &dec, &stderr); code != 0 {
+		t.Fatalf("decrypt exited %d: %s", code, stderr.String())
+	}
+	if dec.String() != "secret-dsn\n" {
+		t.Fatalf("expected secret-dsn, got %q", dec.String())
+	}
+}
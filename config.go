@@ -2,7 +2,6 @@ package goconfig
 
 import (
 	"fmt"
-	"regexp"
 	"time"
 
 	validator "github.com/theflyingcodr/govalidator"
@@ -14,6 +13,7 @@ const (
 	EnvServerHost         = "server.host"
 	EnvServerTLSEnabled   = "server.tls.enabled"
 	EnvServerTLSCert      = "server.tls.cert"
+	EnvServerTLSKey       = "server.tls.key"
 	EnvServerPprofEnabled = "server.pprof.enabled"
 
 	EnvSwaggerHost    = "swagger.host"
@@ -40,6 +40,7 @@ const (
 	EnvHTTPClientTimeout    = "%s.client.timeout"
 	EnvHTTPClientTLSEnabled = "%s.client.tls.enabled"
 	EnvHTTPClientTLSCert    = "%s.client.tls.cert"
+	EnvHTTPClientTLSCA      = "%s.client.tls.ca"
 
 	EnvRedisAddress  = "redis.address"
 	EnvRedisPassword = "redis.password"
@@ -51,6 +52,9 @@ const (
 	LogWarn  = "warn"
 )
 
+// logLevels are the supported log levels, from most to least verbose.
+var logLevels = []string{LogDebug, LogInfo, LogWarn, LogError}
+
 // Config returns strongly typed config values.
 type Config struct {
 	Logging         *Logging
@@ -61,16 +65,45 @@ type Config struct {
 	Swagger         *Swagger
 	Instrumentation *Instrumentation
 	httpClients     map[string]HTTPClientConfig
+	sections        map[string]interface{}
+	hooks           []ValidatorFunc
+}
+
+// ValidatorFunc validates the config as a whole, it can be used to check
+// constraints across sections, such as swagger being disabled in prod.
+//
+// Return a validator.ErrValidation to report failures against config keys,
+// any other error is reported against the key 'config'.
+type ValidatorFunc func(c *Config) error
+
+// AddValidator will add fns to the validators run by Validate, after the
+// built in validation of each section. All failures are returned together:
+//
+//	cfg.AddValidator(func(c *goconfig.Config) error {
+//	    if c.Deployment.IsProd() && c.Swagger.Enabled {
+//	        return validator.NewSingleError(goconfig.EnvSwaggerEnabled, []string{"swagger must be disabled in prod"})
+//	    }
+//	    return nil
+//	})
+func (c *Config) AddValidator(fns ...ValidatorFunc) {
+	c.hooks = append(c.hooks, fns...)
 }
 
 // HTTPClientConfig is a custom http client config struct, returned
 // when CustomHTTPClient is called.
 type HTTPClientConfig struct {
-	Host       string
-	Port       string
+	// Name is the name the client was loaded with.
+	Name string
+	Host string
+	// Port is optional, when empty the default for the scheme is used. Pass
+	// Required(EnvHTTPClientPort) to WithHTTPClient to disallow an empty port.
+	Port       Port
 	TLSEnabled bool
 	TLSCert    bool
-	Timeout    time.Duration
+	// TLSCAPath is the path to a PEM encoded CA used to verify the server,
+	// if empty the system CAs are used.
+	TLSCAPath string
+	Timeout   time.Duration
 }
 
 // CustomHTTPClient will return a custom http client, if not found
@@ -83,20 +116,86 @@ func (c *Config) CustomHTTPClient(name string) *HTTPClientConfig {
 	return &cfg
 }
 
+// Validate will ensure the http client config is valid.
+func (h *HTTPClientConfig) Validate(v validator.ErrValidation) validator.ErrValidation {
+	v = v.Validate(fmt.Sprintf(EnvHTTPClientHost, h.Name), validator.NotEmpty(h.Host))
+	v = v.Validate(fmt.Sprintf(EnvHTTPClientPort, h.Name), h.Port.validate(true))
+	v = v.Validate(fmt.Sprintf(EnvHTTPClientTimeout, h.Name), notNegative(h.Timeout))
+	if h.TLSEnabled {
+		v = v.Validate(fmt.Sprintf(EnvHTTPClientTLSCA, h.Name), validCA(h.TLSCAPath))
+	}
+	return v
+}
+
+// Addr returns the host and port joined as an address, such as
+// example.com:443 or [::1]:8080. If the port is empty only the host is returned.
+func (h *HTTPClientConfig) Addr() string {
+	return joinHostPort(h.Host, h.Port)
+}
+
+// CustomSection will return a custom section added by calling WithSection,
+// if not found nil is returned.
+func (c *Config) CustomSection(name string) interface{} {
+	return c.sections[name]
+}
+
 // Validate will check config values are valid and return a list of failures
 // if any have been found.
+//
+// Every loaded section, http client and custom section implementing Validator
+// is validated, followed by any validators added with AddValidator, so all
+// failures are returned together.
 func (c *Config) Validate() error {
 	vl := validator.New()
-	if c.Db != nil {
-		vl = vl.Validate("db.type", validator.MatchString(string(c.Db.Type), reDbType))
+	for _, sv := range c.validators() {
+		vl = sv.Validate(vl)
+	}
+	for _, fn := range c.hooks {
+		mergeErrs(vl, fn(c))
 	}
 	return vl.Err()
 }
 
+// validators returns each loaded section that implements Validator.
+func (c *Config) validators() []Validator {
+	vv := make([]Validator, 0)
+	if c.Logging != nil {
+		vv = append(vv, c.Logging)
+	}
+	if c.Server != nil {
+		vv = append(vv, c.Server)
+	}
+	if c.Deployment != nil {
+		vv = append(vv, c.Deployment)
+	}
+	if c.Db != nil {
+		vv = append(vv, c.Db)
+	}
+	if c.Redis != nil {
+		vv = append(vv, c.Redis)
+	}
+	if c.Swagger != nil {
+		vv = append(vv, c.Swagger)
+	}
+	if c.Instrumentation != nil {
+		vv = append(vv, c.Instrumentation)
+	}
+	for _, name := range sortedKeys(c.httpClients) {
+		h := c.httpClients[name]
+		vv = append(vv, &h)
+	}
+	for _, name := range sortedKeys(c.sections) {
+		if sv, ok := c.sections[name].(Validator); ok {
+			vv = append(vv, sv)
+		}
+	}
+	return vv
+}
+
 // Deployment contains information relating to the current
 // deployed instance.
 type Deployment struct {
-	Environment string
+	Environment Environment
 	AppName     string
 	Region      string
 	Version     string
@@ -106,7 +205,27 @@ type Deployment struct {
 
 // IsDev determines if this app is running on a dev environment.
 func (d *Deployment) IsDev() bool {
-	return d.Environment == "dev"
+	return d.Environment.IsDev()
+}
+
+// IsTest determines if this app is running on a test environment.
+func (d *Deployment) IsTest() bool {
+	return d.Environment.IsTest()
+}
+
+// IsStaging determines if this app is running on a staging environment.
+func (d *Deployment) IsStaging() bool {
+	return d.Environment.IsStaging()
+}
+
+// IsProd determines if this app is running on a prod environment.
+func (d *Deployment) IsProd() bool {
+	return d.Environment.IsProd()
+}
+
+// Validate will ensure the deployment config is valid.
+func (d *Deployment) Validate(v validator.ErrValidation) validator.ErrValidation {
+	return v.Validate(EnvEnvironment, d.Environment.validate())
 }
 
 // String implements the stringer interface for printing.
@@ -120,15 +239,45 @@ type Logging struct {
 	Level string
 }
 
+// Validate will ensure the log level is known.
+func (l *Logging) Validate(v validator.ErrValidation) validator.ErrValidation {
+	return v.Validate(EnvLogLevel, oneOf(l.Level, logLevels...))
+}
+
 // Server contains all settings required to run a web server.
 type Server struct {
-	Port         string
+	Port         Port
 	Hostname     string
 	TLSCertPath  string
+	TLSKeyPath   string
 	TLSEnabled   bool
 	PProfEnabled bool
 }
 
+// Addr returns the address to listen on, the hostname and port joined
+// such as localhost:8080 or [::1]:8080. An empty hostname listens on all interfaces.
+func (s *Server) Addr() string {
+	return joinHostPort(s.Hostname, s.Port)
+}
+
+// Validate will ensure the server config is valid. When TLS is enabled the
+// cert and key must be readable, a matching pair and the cert must be in date.
+func (s *Server) Validate(v validator.ErrValidation) validator.ErrValidation {
+	v = v.Validate(EnvServerPort, s.Port.validate(false))
+	if !s.TLSEnabled {
+		return v
+	}
+	v = v.Validate(EnvServerTLSCert, validFile(s.TLSCertPath)).
+		Validate(EnvServerTLSKey, validFile(s.TLSKeyPath))
+	if _, ok := v[EnvServerTLSCert]; ok {
+		return v
+	}
+	if _, ok := v[EnvServerTLSKey]; ok {
+		return v
+	}
+	return v.Validate(EnvServerTLSCert, validKeyPair(s.TLSCertPath, s.TLSKeyPath))
+}
+
 // Db contains database information.
 type Db struct {
 	Type       DbType
@@ -137,14 +286,22 @@ type Db struct {
 	Migrate    bool
 }
 
-// Validate will ensure the HeaderClient config is valid.
+// Validate will ensure the db config is valid, the type must be
+// registered and, if the type has a dsn validator, the dsn must pass it.
+// If migrations are enabled the schema path must contain .sql files.
 func (d *Db) Validate(v validator.ErrValidation) validator.ErrValidation {
-	return v.Validate("db.type", validator.MatchString(string(d.Type), reDbType))
+	v = v.Validate(EnvDb, d.Type.validate())
+	if d.Dsn != "" {
+		v = v.Validate(EnvDbDsn, d.Type.validateDsn(d.Dsn))
+	}
+	if d.Migrate {
+		v = v.Validate(EnvDbSchema, validSchemaDir(d.SchemaPath))
+	}
+	return v
 }
 
-var reDbType = regexp.MustCompile(`sqlite|mysql|postgres`)
-
-// DbType is used to restrict the dbs we can support.
+// DbType is used to restrict the dbs we can support, additional
+// types can be added by calling RegisterDbType.
 type DbType string
 
 // Supported database types.
@@ -161,6 +318,11 @@ type Redis struct {
 	Db       uint
 }
 
+// Validate will ensure the redis config is valid.
+func (r *Redis) Validate(v validator.ErrValidation) validator.ErrValidation {
+	return v.Validate(EnvRedisAddress, validator.NotEmpty(r.Address), validHostPort(r.Address))
+}
+
 // Swagger contains swagger configuration.
 type Swagger struct {
 	// Host, if set, will override the default swagger host which
@@ -170,6 +332,15 @@ type Swagger struct {
 	Enabled bool
 }
 
+// Validate will ensure the swagger config is valid, the host
+// is optional but must not contain a scheme or path.
+func (s *Swagger) Validate(v validator.ErrValidation) validator.ErrValidation {
+	if s.Host == "" {
+		return v
+	}
+	return v.Validate(EnvSwaggerHost, noPath(s.Host))
+}
+
 // Instrumentation contains metrics and tracing functionality.
 type Instrumentation struct {
 	// MetricsEnabled will enable / disable metric collection such as prometheus.
@@ -178,16 +349,24 @@ type Instrumentation struct {
 	TracingEnabled bool
 }
 
+// Validate is a no-op as all instrumentation values are valid, it
+// is implemented so every section is a Validator.
+func (i *Instrumentation) Validate(v validator.ErrValidation) validator.ErrValidation {
+	return v
+}
+
 // ConfigurationLoader will load configuration items
 // into a struct that contains a configuration.
 type ConfigurationLoader interface {
-	WithServer() ConfigurationLoader
-	WithEnvironment(appname string) ConfigurationLoader
-	WithLog() ConfigurationLoader
-	WithHTTPClient(name string) ConfigurationLoader
-	WithDb() ConfigurationLoader
-	WithRedis() ConfigurationLoader
-	WithSwagger() ConfigurationLoader
-	WithInstrumentation() ConfigurationLoader
+	WithServer(opts ...SectionOption) ConfigurationLoader
+	WithEnvironment(appname string, opts ...SectionOption) ConfigurationLoader
+	WithLog(opts ...SectionOption) ConfigurationLoader
+	WithHTTPClient(name string, opts ...SectionOption) ConfigurationLoader
+	WithDb(opts ...SectionOption) ConfigurationLoader
+	WithRedis(opts ...SectionOption) ConfigurationLoader
+	WithSwagger(opts ...SectionOption) ConfigurationLoader
+	WithInstrumentation(opts ...SectionOption) ConfigurationLoader
+	WithSection(name string, target interface{}, opts ...SectionOption) ConfigurationLoader
 	Load() *Config
+	LoadE() (*Config, error)
 }
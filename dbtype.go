@@ -0,0 +1,105 @@
+package goconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// DbTypeOption can be supplied when registering a DbType.
+type DbTypeOption func(o *dbTypeOptions)
+
+// dbTypeOptions contains the settings for a registered DbType.
+type dbTypeOptions struct {
+	dsnValidator func(dsn string) error
+}
+
+// WithDsnValidator adds a function used to validate the db.dsn
+// when the db type is in use.
+func WithDsnValidator(fn func(dsn string) error) DbTypeOption {
+	return func(o *dbTypeOptions) {
+		o.dsnValidator = fn
+	}
+}
+
+var (
+	dbTypesMu sync.RWMutex
+	dbTypes   = map[DbType]*dbTypeOptions{
+		DBSqlite:   {},
+		DBMySQL:    {},
+		DBPostgres: {},
+	}
+)
+
+// RegisterDbType will add a database type that can be used in db.type, for
+// example to support sqlserver:
+//
+//	goconfig.RegisterDbType("sqlserver")
+//
+// Registering an existing type will replace its options, this can be used to
+// add a dsn validator to one of the built in types. This should be called
+// before config is loaded, usually in an init func.
+func RegisterDbType(t DbType, opts ...DbTypeOption) {
+	o := &dbTypeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	dbTypesMu.Lock()
+	defer dbTypesMu.Unlock()
+	dbTypes[t] = o
+}
+
+// DbTypes returns all registered database types, sorted.
+func DbTypes() []DbType {
+	dbTypesMu.RLock()
+	defer dbTypesMu.RUnlock()
+	tt := make([]DbType, 0, len(dbTypes))
+	for t := range dbTypes {
+		tt = append(tt, t)
+	}
+	sort.Slice(tt, func(i, j int) bool {
+		return tt[i] < tt[j]
+	})
+	return tt
+}
+
+// Registered returns true if the DbType has been registered.
+func (d DbType) Registered() bool {
+	dbTypesMu.RLock()
+	defer dbTypesMu.RUnlock()
+	_, ok := dbTypes[d]
+	return ok
+}
+
+// validate returns a ValidationFunc ensuring the db type is an exact
+// match for a registered type.
+func (d DbType) validate() validator.ValidationFunc {
+	return func() error {
+		if d.Registered() {
+			return nil
+		}
+		tt := DbTypes()
+		names := make([]string, 0, len(tt))
+		for _, t := range tt {
+			names = append(names, string(t))
+		}
+		return fmt.Errorf("unknown db type %q, expected one of %s", string(d), strings.Join(names, ", "))
+	}
+}
+
+// validateDsn returns a ValidationFunc that runs the dsn validator
+// registered for the db type, if there is one.
+func (d DbType) validateDsn(dsn string) validator.ValidationFunc {
+	return func() error {
+		dbTypesMu.RLock()
+		o, ok := dbTypes[d]
+		dbTypesMu.RUnlock()
+		if !ok || o.dsnValidator == nil {
+			return nil
+		}
+		return o.dsnValidator(dsn)
+	}
+}
@@ -0,0 +1,134 @@
+package goconfig
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Setting describes a single configuration key read by a section
+// along with its default value.
+type Setting struct {
+	// Key is the config key, such as server.port.
+	Key string
+	// Default is used when no value has been supplied, nil means there is no default.
+	Default interface{}
+	// Description explains what the setting is used for.
+	Description string
+	// Required settings must be supplied, LoadE will return an error if missing.
+	Required bool
+	// Secret settings have their values redacted from errors and output.
+	Secret bool
+	// Type is the type the value is loaded as, used when generating a schema. If nil
+	// the type of Default is used, or string if there is no default.
+	Type reflect.Type
+}
+
+// buildDate is the default for env.builddate, the time the app started.
+var buildDate = time.Now().UTC()
+
+// Settings, including defaults, for each of the built in sections.
+var (
+	serverSettings = []Setting{
+		{Key: EnvServerPort, Default: "8080", Description: "Port the web server listens on.", Type: typePort},
+		{Key: EnvServerHost, Description: "Hostname the web server is reachable on."},
+		{Key: EnvServerTLSEnabled, Default: false, Description: "Serve over TLS."},
+		{Key: EnvServerTLSCert, Description: "Path to the PEM encoded TLS certificate."},
+		{Key: EnvServerTLSKey, Description: "Path to the PEM encoded TLS private key."},
+		{Key: EnvServerPprofEnabled, Default: false, Description: "Expose pprof endpoints."},
+	}
+	deploymentSettings = []Setting{
+		{Key: EnvEnvironment, Default: "dev", Description: "Environment the app is deployed to."},
+		{Key: EnvRegion, Default: "test", Description: "Region the app is deployed to."},
+		{Key: EnvVersion, Default: "test", Description: "Version of the app."},
+		{Key: EnvCommit, Default: "test", Description: "Commit hash the app was built from."},
+		{Key: EnvBuildDate, Default: buildDate, Description: "Date the app was built, defaults to the start time."},
+	}
+	loggingSettings = []Setting{
+		{Key: EnvLogLevel, Default: LogInfo, Description: "Minimum log level, one of debug, info, warn or error."},
+	}
+	dbSettings = []Setting{
+		{Key: EnvDb, Description: "Database type, such as sqlite, mysql or postgres.", Required: true},
+		{Key: EnvDbDsn, Description: "Data source name used to connect to the database.", Required: true, Secret: true},
+		{Key: EnvDbSchema, Description: "Path to the database migration files."},
+		{Key: EnvDbMigrate, Default: false, Description: "Run database migrations at startup."},
+	}
+	redisSettings = []Setting{
+		{Key: EnvRedisAddress, Default: "localhost:6379", Description: "Address of the redis server."},
+		{Key: EnvRedisPassword, Description: "Password for the redis server.", Secret: true},
+		{Key: EnvRedisDb, Default: 0, Description: "Redis database number."},
+	}
+	swaggerSettings = []Setting{
+		{Key: EnvSwaggerHost, Description: "Host used in swagger docs, defaults to the server host."},
+		{Key: EnvSwaggerEnabled, Default: false, Description: "Serve swagger endpoints."},
+	}
+	instrumentationSettings = []Setting{
+		{Key: EnvMetricsEnabled, Default: false, Description: "Collect metrics."},
+		{Key: EnvTracingEnabled, Default: false, Description: "Enable tracing."},
+	}
+)
+
+// builtinSettings returns the settings for each of the built in sections.
+func builtinSettings() [][]Setting {
+	return [][]Setting{
+		serverSettings, deploymentSettings, loggingSettings, dbSettings,
+		redisSettings, swaggerSettings, instrumentationSettings,
+	}
+}
+
+// httpClientSettings returns the settings for the http client called name.
+func httpClientSettings(name string) []Setting {
+	return []Setting{
+		{Key: fmt.Sprintf(EnvHTTPClientHost, name), Description: "Host of the " + name + " service.", Required: true},
+		{Key: fmt.Sprintf(EnvHTTPClientPort, name), Description: "Port of the " + name + " service.", Type: typePort},
+		{
+			Key: fmt.Sprintf(EnvHTTPClientTimeout, name), Default: 30 * time.Second,
+			Description: "Request timeout, either a duration such as 30s or a number of seconds.",
+		},
+		{Key: fmt.Sprintf(EnvHTTPClientTLSEnabled, name), Default: false, Description: "Connect over TLS."},
+		{Key: fmt.Sprintf(EnvHTTPClientTLSCert, name), Default: false, Description: "Use a TLS certificate."},
+		{
+			Key:         fmt.Sprintf(EnvHTTPClientTLSCA, name),
+			Description: "Path to a PEM encoded CA used to verify the service, defaults to the system CAs.",
+		},
+	}
+}
+
+// setDefaults will add the defaults for each setting to viper. Defaults supplied using
+// WithDefaults take precedence, as do any defaults already set on the viper instance.
+// Secret settings are also recorded here.
+func (c *ViperConfig) setDefaults(settings []Setting) {
+	c.markSecrets(settings)
+	for _, s := range settings {
+		if s.Default == nil {
+			continue
+		}
+		c.bindEnv(s.Key)
+		if _, ok := c.defaults[s.Key]; ok {
+			// set using WithDefaults.
+			continue
+		}
+		if c.v.IsSet(s.Key) && len(c.Explain(s.Key)) == 0 {
+			// set on the viper instance directly.
+			continue
+		}
+		c.setDefault(s.Key, s.Default)
+	}
+}
+
+// setDefault will set the default for key, recording it so it can be explained.
+func (c *ViperConfig) setDefault(key string, val interface{}) {
+	c.defaults[key] = val
+	c.v.SetDefault(key, val)
+}
+
+// settingDefault returns the default for key from settings, nil is returned
+// if key isn't found.
+func settingDefault(settings []Setting, key string) interface{} {
+	for _, s := range settings {
+		if s.Key == key {
+			return s.Default
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package goconfig
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultKeyEnv is the env var the key used to decrypt enc: values is read
+// from, unless WithDecryptionKeyEnv or WithDecryptionKeyFile is used.
+const DefaultKeyEnv = "GOCONFIG_KEY"
+
+// encPrefix marks a config value as encrypted.
+const encPrefix = "enc:"
+
+// keySize is the size of generated keys, for AES-256.
+const keySize = 32
+
+// GenerateKey returns a new random key, base64 encoded, for use with Encrypt.
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseKey decodes a base64 encoded key, as returned by GenerateKey. The key
+// must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.New("key must be base64 encoded")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("key must be 16, 24 or 32 bytes, got %d", len(key))
+}
+
+// Encrypt encrypts plaintext with key using AES-GCM, returning a value prefixed
+// with enc: that can be used in a config file.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt using key.
+func Decrypt(key []byte, value string) (string, error) {
+	if !strings.HasPrefix(value, encPrefix) {
+		return "", fmt.Errorf("encrypted value must start with %s", encPrefix)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil {
+		return "", errors.New("encrypted value is not base64 encoded")
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("value could not be decrypted, was it encrypted with a different key?")
+	}
+	return string(plaintext), nil
+}
+
+// newGCM returns an AES-GCM cipher using key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ReadKey reads a base64 encoded key from the file at path, if path is empty
+// the key is read from the env var env instead.
+func ReadKey(path, env string) ([]byte, error) {
+	if path != "" {
+		bb, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		key, err := ParseKey(string(bb))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key in %s: %w", path, err)
+		}
+		return key, nil
+	}
+	s := os.Getenv(env)
+	if s == "" {
+		return nil, fmt.Errorf("no decryption key found, set env var %s", env)
+	}
+	key, err := ParseKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key in env var %s: %w", env, err)
+	}
+	return key, nil
+}
+
+// decrypt will replace the enc: value of key with its plaintext, the key is read
+// when the first encrypted value is found. Decrypted values are treated as secrets.
+//
+// If the value can't be decrypted an error naming the key is recorded and the
+// value is left empty.
+func (c *ViperConfig) decrypt(key, value string) {
+	c.markSecret(key)
+	if c.encKey == nil && c.encKeyErr == nil {
+		c.encKey, c.encKeyErr = ReadKey(c.opts.keyFile, c.opts.keyEnv)
+	}
+	err := c.encKeyErr
+	if err == nil {
+		c.resolved[key], err = Decrypt(c.encKey, value)
+	}
+	if err != nil {
+		c.markUnusable(key, fmt.Errorf("failed to decrypt value of %s: %w", key, err))
+	}
+}
@@ -0,0 +1,71 @@
+package goconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecrypt_Loader(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := ParseKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := Encrypt(k, "9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		key    string
+		exp    Port
+		expErr string
+	}{
+		"decrypted": {
+			key: key,
+			exp: "9000",
+		},
+		"wrong key": {
+			key: other,
+			expErr: "[server.port: failed to decrypt value of server.port: value could not be decrypted, " +
+				"was it encrypted with a different key?]",
+		},
+		"no key": {
+			expErr: "[server.port: failed to decrypt value of server.port: no decryption key found, set env var TEST_DECRYPT_KEY]",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("SERVER_PORT", enc)
+			t.Setenv("TEST_DECRYPT_KEY", test.key)
+			loader := NewViperConfig("decrypt", WithoutFileLookup(), WithDecryptionKeyEnv("TEST_DECRYPT_KEY"))
+			cfg, err := loader.WithServer().LoadE()
+			if test.expErr != "" {
+				// the port is left empty, but only the decryption error is reported.
+				if err == nil || err.Error() != test.expErr {
+					t.Fatalf("expected error %q, got %v", test.expErr, err)
+				}
+				if strings.Contains(err.Error(), enc) {
+					t.Fatal("encrypted value should not be reported")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != test.exp {
+				t.Fatalf("expected %s, got %s", test.exp, cfg.Server.Port)
+			}
+			if val := loader.Values()[EnvServerPort]; val != redacted {
+				t.Fatalf("expected decrypted value to be redacted, got %v", val)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package goconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// envFileSuffix is added to an env var name to read its value from a file,
+// for example DB_DSN_FILE=/run/secrets/dsn.
+const envFileSuffix = "_FILE"
+
+// envFile is a value read from the file named by a *_FILE env var.
+type envFile struct {
+	name  string
+	value string
+}
+
+// envPrefixFromAppName converts an app name to an env var prefix,
+// for example my-app becomes MY_APP.
+func envPrefixFromAppName(appname string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, appname)
+}
+
+// envName returns the environment variable name read for a config key,
+// this includes the env prefix if one has been set.
+func (c *ViperConfig) envName(key string) string {
+	if c.opts.envPrefix == "" {
+		return c.unprefixedEnvName(key)
+	}
+	return c.replaceEnv(strings.ToUpper(c.opts.envPrefix + "_" + key))
+}
+
+// unprefixedEnvName returns the environment variable name for a config key
+// without any prefix applied.
+func (c *ViperConfig) unprefixedEnvName(key string) string {
+	return c.replaceEnv(strings.ToUpper(key))
+}
+
+// replaceEnv applies the env key replacer, if set, to name.
+func (c *ViperConfig) replaceEnv(name string) string {
+	if c.opts.envReplacer == nil {
+		return name
+	}
+	return c.opts.envReplacer.Replace(name)
+}
+
+// envNames returns all environment variable names checked for key
+// in the order they are checked.
+func (c *ViperConfig) envNames(key string) []string {
+	names := []string{c.envName(key)}
+	if c.opts.envPrefix != "" && c.opts.envFallback {
+		names = append(names, c.unprefixedEnvName(key))
+	}
+	return append(names, c.envAliases[key]...)
+}
+
+// bindEnvAlias will read key from the env var name, in addition to
+// the env vars derived from the key.
+func (c *ViperConfig) bindEnvAlias(key, name string) {
+	c.envAliases[key] = append(c.envAliases[key], name)
+	if _, ok := c.bound[key]; ok {
+		// already bound, the alias is still checked last as listed by envNames.
+		_ = c.v.BindEnv(key, name)
+	}
+}
+
+// bindEnv will bind the unprefixed environment variable for key as a fallback
+// when a prefix is in use, followed by any aliases. If both the prefixed and unprefixed variables are
+// set with different values, an error is recorded as we can't tell which is correct.
+//
+// Values for key found in a dotenv config file are also added here, at config
+// file precedence, as the key they map to isn't known until it is read.
+func (c *ViperConfig) bindEnv(key string) {
+	if _, ok := c.bound[key]; ok {
+		return
+	}
+	c.bound[key] = struct{}{}
+	// the first name is read by viper's AutomaticEnv, the rest are bound in the order
+	// listed by envNames so the value used is the one Explain reports. Viper will apply
+	// the replacer again, this is a no-op for names already replaced.
+	if names := c.envNames(key)[1:]; len(names) > 0 {
+		_ = c.v.BindEnv(append([]string{key}, names...)...)
+	}
+	c.bindDotenv(key)
+	c.bindEnvFile(key)
+	if c.opts.envPrefix == "" || !c.opts.envFallback {
+		return
+	}
+	prefixed, unprefixed := c.envName(key), c.unprefixedEnvName(key)
+	pv, pok := os.LookupEnv(prefixed)
+	uv, uok := os.LookupEnv(unprefixed)
+	if pok && uok && pv != uv {
+		c.addErr(key, fmt.Errorf("env vars %s and %s are both set with different values, remove %s",
+			prefixed, unprefixed, unprefixed))
+	}
+}
+
+// bindDotenv will add the value for key to the config if it is found in a
+// dotenv config file.
+func (c *ViperConfig) bindDotenv(key string) {
+	for _, f := range c.files {
+		if !f.dotenv {
+			continue
+		}
+		for _, name := range c.envNames(key) {
+			if _, ok := f.settings[name]; ok {
+				c.addErr(errKeyConfigFile, c.syncConfig())
+				return
+			}
+		}
+	}
+}
+
+// bindEnvFile will read the value for key from a file if a *_FILE env var, such as
+// DB_DSN_FILE, is set. Each env var name for key is checked in order, the first
+// set, with or without the suffix, is used. The contents are trimmed and treated as a secret.
+//
+// If both an env var and its *_FILE variant are set an error is recorded.
+func (c *ViperConfig) bindEnvFile(key string) {
+	for _, name := range c.envNames(key) {
+		fileName := name + envFileSuffix
+		path := os.Getenv(fileName)
+		plain := os.Getenv(name) != ""
+		if plain && path != "" {
+			c.addErr(key, fmt.Errorf("env vars %s and %s are both set, only one can be used", name, fileName))
+			return
+		}
+		if plain {
+			return
+		}
+		if path == "" {
+			continue
+		}
+		bb, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			c.addErr(key, fmt.Errorf("failed to read file from %s: %w", fileName, err))
+			return
+		}
+		c.envFiles[key] = envFile{name: fileName, value: strings.TrimSpace(string(bb))}
+		c.markSecret(key)
+		return
+	}
+}
@@ -0,0 +1,122 @@
+package goconfig
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindEnvFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"host":     "  file-host\n\n",
+		"prefixed": "prefixed-host\n",
+	})
+	host, prefixed := filepath.Join(dir, "host"), filepath.Join(dir, "prefixed")
+	tests := map[string]struct {
+		opts       []ViperOption
+		env        map[string]string
+		expHost    string
+		expSources []Source
+		expErr     string
+	}{
+		"file trimmed": {
+			env:        map[string]string{"SERVER_HOST_FILE": host},
+			expHost:    "file-host",
+			expSources: []Source{{Type: SourceEnv, Location: "SERVER_HOST_FILE", Value: "file-host"}},
+		},
+		"empty file env var ignored": {
+			env:     map[string]string{"SERVER_HOST_FILE": ""},
+			expHost: "",
+		},
+		"plain and file set": {
+			env:    map[string]string{"SERVER_HOST": "env-host", "SERVER_HOST_FILE": host},
+			expErr: "env vars SERVER_HOST and SERVER_HOST_FILE are both set, only one can be used",
+		},
+		"unreadable file": {
+			env:    map[string]string{"SERVER_HOST_FILE": filepath.Join(dir, "missing")},
+			expErr: "failed to read file from SERVER_HOST_FILE",
+		},
+		"prefixed file over unprefixed file": {
+			opts:    []ViperOption{WithEnvPrefix("app")},
+			env:     map[string]string{"APP_SERVER_HOST_FILE": prefixed, "SERVER_HOST_FILE": host},
+			expHost: "prefixed-host",
+			expSources: []Source{
+				{Type: SourceEnv, Location: "APP_SERVER_HOST_FILE", Value: "prefixed-host"},
+			},
+		},
+		"prefixed env over unprefixed file": {
+			opts:    []ViperOption{WithEnvPrefix("app")},
+			env:     map[string]string{"APP_SERVER_HOST": "env-host", "SERVER_HOST_FILE": host},
+			expHost: "env-host",
+			expSources: []Source{
+				{Type: SourceEnv, Location: "APP_SERVER_HOST", Value: "env-host"},
+			},
+		},
+		"prefixed file over unprefixed env": {
+			opts:    []ViperOption{WithEnvPrefix("app")},
+			env:     map[string]string{"APP_SERVER_HOST_FILE": prefixed, "SERVER_HOST": "env-host"},
+			expHost: "prefixed-host",
+			expSources: []Source{
+				{Type: SourceEnv, Location: "APP_SERVER_HOST_FILE", Value: "prefixed-host"},
+				{Type: SourceEnv, Location: "SERVER_HOST", Value: "env-host"},
+			},
+		},
+		"unprefixed file used as fallback": {
+			opts:       []ViperOption{WithEnvPrefix("app")},
+			env:        map[string]string{"SERVER_HOST_FILE": host},
+			expHost:    "file-host",
+			expSources: []Source{{Type: SourceEnv, Location: "SERVER_HOST_FILE", Value: "file-host"}},
+		},
+		"unprefixed file ignored without fallback": {
+			opts:    []ViperOption{WithEnvPrefix("app"), WithoutEnvFallback()},
+			env:     map[string]string{"SERVER_HOST_FILE": host},
+			expHost: "",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			loader := NewViperConfig("env", append([]ViperOption{WithoutFileLookup()}, test.opts...)...)
+			cfg, err := loader.WithServer().LoadE()
+			if test.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Hostname != test.expHost {
+				t.Fatalf("expected host %q, got %q", test.expHost, cfg.Server.Hostname)
+			}
+			if sources := loader.Explain(EnvServerHost); !reflect.DeepEqual(sources, test.expSources) {
+				t.Fatalf("expected sources %v, got %v", test.expSources, sources)
+			}
+		})
+	}
+}
+
+func TestBindEnvFile_Redacted(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"host": "secret-host\n"})
+	t.Setenv("SERVER_HOST_FILE", filepath.Join(dir, "host"))
+	t.Setenv("SERVER_PORT", "9000")
+	loader := NewViperConfig("env", WithoutFileLookup())
+	if _, err := loader.WithServer().LoadE(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !loader.IsSecret(EnvServerHost) {
+		t.Fatal("expected values read from a file to be secret")
+	}
+	vals := loader.Values()
+	if vals[EnvServerHost] != redacted {
+		t.Fatalf("expected %s to be redacted, got %v", EnvServerHost, vals[EnvServerHost])
+	}
+	if vals[EnvServerPort] != "9000" {
+		t.Fatalf("expected %s to be 9000, got %v", EnvServerPort, vals[EnvServerPort])
+	}
+}
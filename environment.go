@@ -0,0 +1,102 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// Environment is the environment an app is deployed to.
+type Environment string
+
+// Known environments.
+const (
+	EnvironmentDev     Environment = "dev"
+	EnvironmentTest    Environment = "test"
+	EnvironmentStaging Environment = "staging"
+	EnvironmentProd    Environment = "prod"
+)
+
+// environments are the known environments, in the order they are reported.
+var environments = []Environment{EnvironmentDev, EnvironmentTest, EnvironmentStaging, EnvironmentProd}
+
+// environmentAliases map common alternative names to a known environment.
+var environmentAliases = map[string]Environment{
+	"development": EnvironmentDev,
+	"local":       EnvironmentDev,
+	"testing":     EnvironmentTest,
+	"stage":       EnvironmentStaging,
+	"production":  EnvironmentProd,
+	"live":        EnvironmentProd,
+}
+
+// NormaliseEnvironment will lower-case and trim s and convert any aliases, such
+// as production, to the known Environment. Unknown values are returned as is
+// and will fail validation.
+func NormaliseEnvironment(s string) Environment {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if env, ok := environmentAliases[s]; ok {
+		return env
+	}
+	return Environment(s)
+}
+
+// ParseEnvironment will normalise s and return an error if it isn't
+// a known environment.
+func ParseEnvironment(s string) (Environment, error) {
+	env := NormaliseEnvironment(s)
+	if err := env.validate()(); err != nil {
+		return env, err
+	}
+	return env, nil
+}
+
+// Known returns true if e is one of the known environments.
+func (e Environment) Known() bool {
+	for _, env := range environments {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDev returns true if e is the dev environment.
+func (e Environment) IsDev() bool {
+	return e == EnvironmentDev
+}
+
+// IsTest returns true if e is the test environment.
+func (e Environment) IsTest() bool {
+	return e == EnvironmentTest
+}
+
+// IsStaging returns true if e is the staging environment.
+func (e Environment) IsStaging() bool {
+	return e == EnvironmentStaging
+}
+
+// IsProd returns true if e is the prod environment.
+func (e Environment) IsProd() bool {
+	return e == EnvironmentProd
+}
+
+// String implements the stringer interface.
+func (e Environment) String() string {
+	return string(e)
+}
+
+// validate returns a ValidationFunc that ensures e is known.
+func (e Environment) validate() validator.ValidationFunc {
+	return func() error {
+		if e.Known() {
+			return nil
+		}
+		known := make([]string, 0, len(environments))
+		for _, env := range environments {
+			known = append(known, string(env))
+		}
+		return fmt.Errorf("unknown environment %q, expected one of %s", string(e), strings.Join(known, ", "))
+	}
+}
@@ -0,0 +1,126 @@
+package goconfig
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source types, in order of precedence.
+const (
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+	SourceFile    = "file"
+	SourceRemote  = "remote"
+	SourceDefault = "default"
+)
+
+// Source describes a value supplied for a config key and where it came from.
+type Source struct {
+	// Type is the kind of source, one of the Source* constants.
+	Type string
+	// Location identifies the source, such as the flag, env var name or file path.
+	Location string
+	// Value is the raw value supplied.
+	Value interface{}
+}
+
+// String implements the stringer interface for printing.
+func (s Source) String() string {
+	if s.Location == "" {
+		return fmt.Sprintf("%s: %v", s.Type, s.Value)
+	}
+	return fmt.Sprintf("%s %s: %v", s.Type, s.Location, s.Value)
+}
+
+// Explain returns every source that supplies a value for key in order of
+// precedence, the first is the value in use. An empty slice is returned if
+// no value has been supplied.
+//
+// This is useful when debugging where a value has come from.
+func (c *ViperConfig) Explain(key string) []Source {
+	key = strings.ToLower(key)
+	var sources []Source
+	if val, ok := c.flagValue(key); ok {
+		sources = append(sources, Source{Type: SourceFlag, Location: "--" + flagName(key), Value: val})
+	}
+	for _, name := range c.envNames(key) {
+		if val := os.Getenv(name); val != "" {
+			sources = append(sources, Source{Type: SourceEnv, Location: name, Value: val})
+		}
+		if f, ok := c.envFiles[key]; ok && f.name == name+envFileSuffix {
+			sources = append(sources, Source{Type: SourceEnv, Location: f.name, Value: f.value})
+		}
+	}
+	for i := len(c.files) - 1; i >= 0; i-- {
+		f := c.files[i]
+		if !f.dotenv {
+			if val, ok := f.settings[key]; ok {
+				sources = append(sources, Source{Type: f.sourceType(), Location: f.path, Value: val})
+			}
+			continue
+		}
+		for _, name := range c.envNames(key) {
+			if val, ok := f.settings[name]; ok {
+				sources = append(sources, Source{Type: SourceFile, Location: f.path, Value: val})
+				break
+			}
+		}
+	}
+	if val, ok := c.defaults[key]; ok {
+		sources = append(sources, Source{Type: SourceDefault, Value: val})
+	}
+	return sources
+}
+
+// Values returns the value in use for each key read by the loaded sections, with
+// secret values redacted, so the effective config can be safely output.
+func (c *ViperConfig) Values() map[string]interface{} {
+	out := make(map[string]interface{}, len(c.bound))
+	for k := range c.bound {
+		out[k] = c.RedactValue(k, c.lookup(k))
+	}
+	return out
+}
+
+// FileSources returns every key found in the config files mapped to the path
+// of the file that supplied its value. Keys from dotenv files are the env var name.
+func (c *ViperConfig) FileSources() map[string]string {
+	out := map[string]string{}
+	for _, f := range c.files {
+		if f.remote {
+			continue
+		}
+		for k := range f.settings {
+			out[k] = f.path
+		}
+	}
+	return out
+}
+
+// ExplainFiles returns a line per key found in the config files, sorted by
+// key, showing the file that supplied its value:
+//
+//	server.port: /etc/my-app/config.prod.yaml
+func (c *ViperConfig) ExplainFiles() string {
+	sources := c.FileSources()
+	keys := make([]string, 0, len(sources))
+	for k := range sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "%s: %s\n", k, sources[k])
+	}
+	return sb.String()
+}
+
+// sourceType returns the Source type for values read from f.
+func (f configFile) sourceType() string {
+	if f.remote {
+		return SourceRemote
+	}
+	return SourceFile
+}
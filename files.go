@@ -0,0 +1,309 @@
+package goconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
+)
+
+// configExts are the config file extensions searched for, each
+// file is parsed according to its extension.
+var configExts = []string{"yaml", "yml", "toml", "json", "hcl", "ini", "env"}
+
+// configLocal is the name of the config overlay for local development.
+const configLocal = "local"
+
+// configFile is a config file that has been read, settings are keyed by
+// config key or, for dotenv files, by environment variable name.
+//
+// Values read from a remote provider are also stored as a configFile, with
+// the provider location as the path, so they are merged in the same way.
+type configFile struct {
+	path     string
+	dotenv   bool
+	remote   bool
+	settings map[string]interface{}
+}
+
+// configTypeForExt returns the config type used to parse a file with extension ext.
+func configTypeForExt(ext string) string {
+	switch ext {
+	case "yml":
+		return "yaml"
+	case "dotenv":
+		return "env"
+	}
+	return ext
+}
+
+// findConfigFile will search each path in order for a file called name with
+// a supported extension, returning the first found. If configType is set only
+// files of that type are considered.
+//
+// If a directory contains more than one candidate an error is returned rather
+// than guessing which should be used. If no file is found, an empty path is returned.
+func findConfigFile(paths []string, name, configType string) (string, error) {
+	for _, dir := range paths {
+		dir = os.ExpandEnv(dir)
+		var found []string
+		for _, ext := range configExts {
+			if configType != "" && configTypeForExt(ext) != configTypeForExt(configType) {
+				continue
+			}
+			p := filepath.Join(dir, name+"."+ext)
+			if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+				found = append(found, p)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			return "", fmt.Errorf("found multiple config files in %s (%s), only one is supported",
+				dir, strings.Join(found, ", "))
+		}
+	}
+	return "", nil
+}
+
+// readConfigFiles will search for and read the config files, each is merged
+// over the last in the order:
+//
+//	config.<ext>
+//	config.<environment>.<ext>
+//	config.local.<ext>
+//
+// where environment is read from env.environment. Missing files are not treated as an error.
+//
+// If a config file has been given using WithConfigFile only that file is read.
+func (c *ViperConfig) readConfigFiles() {
+	if c.opts.configFile != "" {
+		c.readConfigPath(c.opts.configFile)
+		return
+	}
+	c.readConfigFile(c.opts.configName)
+	if env := c.environment(); env != "" {
+		c.readConfigFile(c.opts.configName + "." + string(env))
+	}
+	c.readConfigFile(c.opts.configName + "." + configLocal)
+}
+
+// readConfigFile will search for the config file called name and merge it.
+func (c *ViperConfig) readConfigFile(name string) {
+	path, err := findConfigFile(c.opts.searchPaths, name, c.opts.configType)
+	if err != nil {
+		c.addErr(errKeyConfigFile, err)
+		return
+	}
+	if path == "" {
+		// Config file not found, env vars and defaults will be used.
+		return
+	}
+	c.readConfigPath(path)
+}
+
+// readConfigPath will read and merge the config file at path.
+func (c *ViperConfig) readConfigPath(path string) {
+	configType := c.opts.configType
+	if configType == "" {
+		configType = configTypeForExt(strings.TrimPrefix(filepath.Ext(path), "."))
+	}
+	if err := c.mergeConfigFile(path, configType); err != nil {
+		c.addErr(errKeyConfigFile, fmt.Errorf("failed to read config file %s: %w", path, err))
+	}
+}
+
+// mergeConfigFile will parse the file at path as configType and merge it
+// into the config, overriding values from files already read.
+//
+// Dotenv files contain environment variable names rather than config keys,
+// these are merged when the key is read, see bindDotenv.
+func (c *ViperConfig) mergeConfigFile(path, configType string) error {
+	if configTypeForExt(configType) == "env" {
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		env, err := gotenv.StrictParse(f)
+		if err != nil {
+			return err
+		}
+		settings := make(map[string]interface{}, len(env))
+		for k, val := range env {
+			settings[k] = val
+		}
+		c.files = append(c.files, configFile{path: path, dotenv: true, settings: settings})
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType(configType)
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+	all := v.AllSettings()
+	if configType == "hcl" {
+		all = flattenHCLBlocks(all)
+	}
+	settings := map[string]interface{}{}
+	flattenMap("", all, settings)
+	c.files = append(c.files, configFile{path: path, settings: settings})
+	return c.syncConfig()
+}
+
+// syncConfig will replace the viper config with the merge of every config file
+// read, later files override earlier ones key by key. Dotenv values are included
+// for keys that have been bound.
+//
+// Viper won't merge values of differing types, such as a port given as an int
+// in one file and a string in another, so the merge is done here instead.
+func (c *ViperConfig) syncConfig() error {
+	merged := map[string]interface{}{}
+	for _, f := range c.files {
+		if !f.dotenv {
+			for k, val := range f.settings {
+				setNested(merged, k, val)
+			}
+			continue
+		}
+		for key := range c.bound {
+			for _, name := range c.envNames(key) {
+				if val, ok := f.settings[name]; ok {
+					setNested(merged, key, val)
+					break
+				}
+			}
+		}
+	}
+	// clear values from earlier merges, then add the merge as is so values, such as
+	// large integers, keep the type they were parsed as.
+	c.v.SetConfigType("json")
+	if err := c.v.ReadConfig(strings.NewReader("{}")); err != nil {
+		return err
+	}
+	return c.v.MergeConfigMap(stringKeys(merged).(map[string]interface{}))
+}
+
+// fileValue returns the value for key from the config file with the highest
+// precedence that contains it.
+func (c *ViperConfig) fileValue(key string) (configFile, interface{}, bool) {
+	for i := len(c.files) - 1; i >= 0; i-- {
+		f := c.files[i]
+		if !f.dotenv {
+			if val, ok := f.settings[key]; ok {
+				return f, val, true
+			}
+			continue
+		}
+		for _, name := range c.envNames(key) {
+			if val, ok := f.settings[name]; ok {
+				return f, val, true
+			}
+		}
+	}
+	return configFile{}, nil, false
+}
+
+// environment returns the environment used to select a config overlay, this
+// is read from the env vars, then config files and then defaults.
+func (c *ViperConfig) environment() Environment {
+	for _, name := range c.envNames(EnvEnvironment) {
+		if val := os.Getenv(name); val != "" {
+			return NormaliseEnvironment(val)
+		}
+	}
+	if _, val, ok := c.fileValue(EnvEnvironment); ok {
+		return NormaliseEnvironment(cast.ToString(val))
+	}
+	if val := c.v.GetString(EnvEnvironment); val != "" {
+		return NormaliseEnvironment(val)
+	}
+	return NormaliseEnvironment(cast.ToString(settingDefault(deploymentSettings, EnvEnvironment)))
+}
+
+// ConfigFiles returns the paths of the config files that have been read,
+// in the order they were merged. Later files override earlier ones.
+func (c *ViperConfig) ConfigFiles() []string {
+	paths := make([]string, 0, len(c.files))
+	for _, f := range c.files {
+		if !f.remote {
+			paths = append(paths, f.path)
+		}
+	}
+	return paths
+}
+
+// setNested will set val in m at the dotted key, creating maps as required.
+func setNested(m map[string]interface{}, key string, val interface{}) {
+	parts := strings.Split(key, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[p] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = val
+}
+
+// stringKeys will convert any map[interface{}]interface{} values, as returned
+// by the yaml parser, to map[string]interface{} so viper can merge them.
+func stringKeys(val interface{}) interface{} {
+	switch t := val.(type) {
+	case map[interface{}]interface{}:
+		return stringKeys(cast.ToStringMap(t))
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			out[k] = stringKeys(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, v := range t {
+			out[i] = stringKeys(v)
+		}
+		return out
+	}
+	return val
+}
+
+// flattenMap will add each value in m to out keyed by its full dotted key.
+func flattenMap(prefix string, m map[string]interface{}, out map[string]interface{}) {
+	for k, val := range m {
+		key := strings.ToLower(k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := val.(map[string]interface{}); ok {
+			flattenMap(key, nested, out)
+			continue
+		}
+		out[key] = val
+	}
+}
+
+// flattenHCLBlocks will unwrap HCL blocks, which are decoded as a list
+// containing a single map, so they can be read as nested keys.
+func flattenHCLBlocks(m map[string]interface{}) map[string]interface{} {
+	for k, val := range m {
+		switch t := val.(type) {
+		case []map[string]interface{}:
+			if len(t) == 1 {
+				m[k] = flattenHCLBlocks(t[0])
+			}
+		case map[string]interface{}:
+			m[k] = flattenHCLBlocks(t)
+		}
+	}
+	return m
+}
@@ -0,0 +1,224 @@
+package goconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes each file, keyed by name, to a new temp dir and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadConfigFiles(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		env      map[string]string
+		expPort  Port
+		expHost  string
+		expFiles []string
+		expErr   string
+	}{
+		"no files": {
+			expPort: "8080",
+		},
+		"base file": {
+			files:    map[string]string{"config.yaml": "server:\n  port: 9000\n  host: base\n"},
+			expPort:  "9000",
+			expHost:  "base",
+			expFiles: []string{"config.yaml"},
+		},
+		"environment overlay over base in another format": {
+			files: map[string]string{
+				"config.yaml":      "server:\n  port: 9000\n  host: base\n",
+				"config.prod.toml": "[server]\nport = \"9100\"\n",
+			},
+			env:      map[string]string{"ENV_ENVIRONMENT": "production"},
+			expPort:  "9100",
+			expHost:  "base",
+			expFiles: []string{"config.yaml", "config.prod.toml"},
+		},
+		"overlay for another environment is ignored": {
+			files: map[string]string{
+				"config.yaml":      "server:\n  port: 9000\n",
+				"config.prod.toml": "[server]\nport = 9100\n",
+			},
+			env:      map[string]string{"ENV_ENVIRONMENT": "dev"},
+			expPort:  "9000",
+			expFiles: []string{"config.yaml"},
+		},
+		"environment read from base file": {
+			files: map[string]string{
+				"config.yaml":         "env:\n  environment: staging\nserver:\n  port: 9000\n",
+				"config.staging.json": `{"server": {"port": 9200}}`,
+			},
+			expPort:  "9200",
+			expFiles: []string{"config.yaml", "config.staging.json"},
+		},
+		"local over environment overlay": {
+			files: map[string]string{
+				"config.yaml":       "server:\n  port: 9000\n",
+				"config.prod.toml":  "[server]\nport = 9100\nhost = \"prod\"\n",
+				"config.local.json": `{"server": {"port": "9300"}}`,
+			},
+			env:      map[string]string{"ENV_ENVIRONMENT": "prod"},
+			expPort:  "9300",
+			expHost:  "prod",
+			expFiles: []string{"config.yaml", "config.prod.toml", "config.local.json"},
+		},
+		"dotenv overlay": {
+			files: map[string]string{
+				"config.yaml":      "server:\n  port: 9000\n  host: base\n",
+				"config.local.env": "SERVER_PORT=9400\n",
+			},
+			expPort:  "9400",
+			expHost:  "base",
+			expFiles: []string{"config.yaml", "config.local.env"},
+		},
+		"env over files": {
+			files:    map[string]string{"config.yaml": "server:\n  port: 9000\n"},
+			env:      map[string]string{"SERVER_PORT": "9500"},
+			expPort:  "9500",
+			expFiles: []string{"config.yaml"},
+		},
+		"multiple candidates": {
+			files: map[string]string{
+				"config.yaml": "server:\n  port: 9000\n",
+				"config.json": `{"server": {"port": 9100}}`,
+			},
+			expPort: "8080",
+			expErr:  "found multiple config files",
+		},
+		"invalid file": {
+			files:   map[string]string{"config.yaml": "server: [port\n"},
+			expPort: "8080",
+			expErr:  "failed to read config file",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			dir := writeFiles(t, test.files)
+			loader := NewViperConfig("files", WithSearchPaths(dir))
+			cfg, err := loader.WithServer().LoadE()
+			if test.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expErr) {
+					t.Fatalf("expected error containing %q, got %v", test.expErr, err)
+				}
+				cfg = loader.Config
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != test.expPort || cfg.Server.Hostname != test.expHost {
+				t.Fatalf("expected %s:%s, got %s:%s", test.expHost, test.expPort, cfg.Server.Hostname, cfg.Server.Port)
+			}
+			var files []string
+			for _, f := range loader.ConfigFiles() {
+				files = append(files, filepath.Base(f))
+			}
+			if !reflect.DeepEqual(files, test.expFiles) {
+				t.Fatalf("expected files %v, got %v", test.expFiles, files)
+			}
+		})
+	}
+}
+
+func TestReadConfigFiles_Types(t *testing.T) {
+	type payments struct {
+		ID    int64    `config:"id"`
+		Rate  float64  `config:"rate"`
+		Hosts []string `config:"hosts"`
+	}
+	tests := map[string]struct {
+		file string
+		exp  payments
+	}{
+		"yaml": {
+			file: "config.yaml",
+			exp:  payments{ID: 9007199254740993, Rate: 0.25, Hosts: []string{"a", "b"}},
+		},
+		"toml": {
+			file: "config.toml",
+			exp:  payments{ID: 9007199254740993, Rate: 0.25, Hosts: []string{"a", "b"}},
+		},
+	}
+	contents := map[string]string{
+		"config.yaml": "payments:\n  id: 9007199254740993\n  rate: 0.25\n  hosts: [a, b]\n",
+		"config.toml": "[payments]\nid = 9007199254740993\nrate = 0.25\nhosts = [\"a\", \"b\"]\n",
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{
+				test.file: contents[test.file],
+				// an overlay forces the files to be merged.
+				"config.local.yaml": "server:\n  host: local\n",
+			})
+			var p payments
+			if _, err := NewViperConfig("files", WithSearchPaths(dir)).WithSection("payments", &p).LoadE(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(p, test.exp) {
+				t.Fatalf("expected %+v, got %+v", test.exp, p)
+			}
+		})
+	}
+}
+
+func TestWithConfigFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"app.yml":          "server:\n  port: 9000\n",
+		"config.local.yml": "server:\n  port: 9100\n",
+	})
+	path := filepath.Join(dir, "app.yml")
+	loader := NewViperConfig("files", WithConfigFile(path), WithSearchPaths(dir))
+	cfg, err := loader.WithServer().LoadE()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.Server.Port != "9000" {
+		t.Fatalf("expected overlays to be ignored, got %s", cfg.Server.Port)
+	}
+	if files := loader.ConfigFiles(); !reflect.DeepEqual(files, []string{path}) {
+		t.Fatalf("expected only %s, got %v", path, files)
+	}
+}
+
+func TestExplainFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml":      "server:\n  port: 9000\n  host: base\n",
+		"config.prod.toml": "[server]\nport = 9100\n",
+		"config.local.env": "DB_DSN=postgres://\n",
+	})
+	t.Setenv("ENV_ENVIRONMENT", "prod")
+	loader := NewViperConfig("files", WithSearchPaths(dir))
+	loader.WithServer()
+	base, prod, local := filepath.Join(dir, "config.yaml"), filepath.Join(dir, "config.prod.toml"),
+		filepath.Join(dir, "config.local.env")
+	exp := "DB_DSN: " + local + "\nserver.host: " + base + "\nserver.port: " + prod + "\n"
+	if got := loader.ExplainFiles(); got != exp {
+		t.Fatalf("expected:\n%s\ngot:\n%s", exp, got)
+	}
+	sources := loader.Explain(EnvServerPort)
+	expSources := []Source{
+		{Type: SourceFile, Location: prod, Value: int64(9100)},
+		{Type: SourceFile, Location: base, Value: 9000},
+		{Type: SourceDefault, Value: "8080"},
+	}
+	if !reflect.DeepEqual(sources, expSources) {
+		t.Fatalf("expected %v, got %v", expSources, sources)
+	}
+}
@@ -0,0 +1,228 @@
+package goconfig
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// FlagSet returns a pflag.FlagSet with a flag for each key read by the loaded
+// sections, http clients and custom sections. The flag name is the key with dots
+// replaced by dashes, so server.port is set using --server-port, the usage text is
+// the description of the setting and the default is the default of the setting.
+//
+// Flags take precedence over every other source, including env vars. Sections are
+// read when each With* method is called so FlagSet should be called after them, once
+// the flags have been parsed LoadE will load the sections again using their values:
+//
+//	loader := goconfig.NewViperConfig("my-app")
+//	loader.WithServer().WithDb()
+//	fs := loader.FlagSet()
+//	if err := fs.Parse(os.Args[1:]); err != nil {
+//	    log.Fatal(err)
+//	}
+//	cfg, err := loader.LoadE()
+//
+// The same FlagSet is returned by each call, with flags added for any sections loaded
+// since. It can be merged into a cobra command using cmd.Flags().AddFlagSet(fs), or into
+// a standard library flag.FlagSet using AddGoFlags.
+func (c *ViperConfig) FlagSet() *pflag.FlagSet {
+	if c.flagSet == nil {
+		c.flagSet = pflag.NewFlagSet(c.opts.appName, pflag.ContinueOnError)
+	}
+	for _, ss := range c.loadedSettings() {
+		for _, s := range ss {
+			t := s.Type
+			if t == nil && s.Default != nil {
+				t = reflect.TypeOf(s.Default)
+			}
+			c.addFlag(s.Key, t, s.Description, s.Default)
+		}
+	}
+	for _, name := range sortedKeys(c.sections) {
+		walkStruct(name, reflect.TypeOf(c.sections[name]).Elem(), func(key string, sf reflect.StructField) {
+			var def interface{}
+			if d, ok := sf.Tag.Lookup(tagDefault); ok {
+				def = d
+			}
+			c.addFlag(key, sf.Type, sf.Tag.Get(tagDescription), def)
+		})
+	}
+	return c.flagSet
+}
+
+// AddGoFlags will add each flag returned by FlagSet to fs, for apps using the
+// standard library flag package. Values parsed by fs are set on the FlagSet.
+func (c *ViperConfig) AddGoFlags(fs *flag.FlagSet) {
+	pfs := c.FlagSet()
+	pfs.VisitAll(func(f *pflag.Flag) {
+		if fs.Lookup(f.Name) != nil {
+			return
+		}
+		fs.Var(&goFlagValue{fs: pfs, flag: f}, f.Name, f.Usage)
+	})
+}
+
+// goFlagValue adapts a pflag.Flag to a flag.Value, setting the value through
+// the pflag.FlagSet so it is marked as changed.
+type goFlagValue struct {
+	fs   *pflag.FlagSet
+	flag *pflag.Flag
+}
+
+// String implements flag.Value.
+func (g *goFlagValue) String() string {
+	if g.flag == nil {
+		// the flag package calls String on a zero value to find the default.
+		return ""
+	}
+	return g.flag.Value.String()
+}
+
+// Set implements flag.Value.
+func (g *goFlagValue) Set(s string) error {
+	return g.fs.Set(g.flag.Name, s)
+}
+
+// IsBoolFlag allows bool flags to be set without a value, such as -server-tls-enabled.
+func (g *goFlagValue) IsBoolFlag() bool {
+	return g.flag != nil && g.flag.Value.Type() == "bool"
+}
+
+// flagName returns the name of the flag for key.
+func flagName(key string) string {
+	return strings.ReplaceAll(key, ".", "-")
+}
+
+// loadedSettings returns the settings for each built in section and
+// http client that has been loaded.
+func (c *ViperConfig) loadedSettings() [][]Setting {
+	var out [][]Setting
+	for _, s := range []struct {
+		loaded   bool
+		settings []Setting
+	}{
+		{loaded: c.Server != nil, settings: serverSettings},
+		{loaded: c.Deployment != nil, settings: deploymentSettings},
+		{loaded: c.Logging != nil, settings: loggingSettings},
+		{loaded: c.Db != nil, settings: dbSettings},
+		{loaded: c.Redis != nil, settings: redisSettings},
+		{loaded: c.Swagger != nil, settings: swaggerSettings},
+		{loaded: c.Instrumentation != nil, settings: instrumentationSettings},
+	} {
+		if s.loaded {
+			out = append(out, s.settings)
+		}
+	}
+	for _, name := range sortedKeys(c.httpClients) {
+		out = append(out, httpClientSettings(name))
+	}
+	return out
+}
+
+// addFlag will add a flag for key, typed using t, if it doesn't already exist.
+// Secret and time defaults aren't shown.
+func (c *ViperConfig) addFlag(key string, t reflect.Type, usage string, def interface{}) {
+	name := flagName(key)
+	if c.flagSet.Lookup(name) != nil {
+		return
+	}
+	if d, ok := c.defaults[key]; ok {
+		// includes defaults overridden using WithDefaults.
+		def = d
+	}
+	if _, ok := c.secrets[key]; ok || t == typeTime {
+		def = nil
+	}
+	kind := reflect.String
+	if t != nil && t != typeDuration && t != typeTime {
+		kind = t.Kind()
+	}
+	fs := c.flagSet
+	// nolint:exhaustive // only supporting common config types
+	switch kind {
+	case reflect.Bool:
+		fs.Bool(name, cast.ToBool(def), usage)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fs.Int64(name, cast.ToInt64(def), usage)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fs.Uint64(name, cast.ToUint64(def), usage)
+	case reflect.Float32, reflect.Float64:
+		fs.Float64(name, cast.ToFloat64(def), usage)
+	case reflect.Slice:
+		var ss []string
+		if s, ok := def.(string); ok && s != "" {
+			ss = strings.Split(s, ",")
+		} else {
+			ss = cast.ToStringSlice(def)
+		}
+		fs.StringSlice(name, ss, usage)
+	default:
+		fs.String(name, cast.ToString(def), usage)
+	}
+	if c.flags == nil {
+		c.flags = map[string]*pflag.Flag{}
+	}
+	c.flags[key] = fs.Lookup(name)
+}
+
+// flagValue returns the value of the flag for key if it has been set.
+func (c *ViperConfig) flagValue(key string) (interface{}, bool) {
+	f, ok := c.flags[key]
+	if !ok || !f.Changed {
+		return nil, false
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice(), true
+	}
+	return f.Value.String(), true
+}
+
+// flagsChanged returns true if any flag has been set.
+func (c *ViperConfig) flagsChanged() bool {
+	for _, f := range c.flags {
+		if f.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// addLoader will add load, used by reload to load the section key again. If the
+// section has already been added its loader is replaced, keeping its position, so
+// calling a With* method again, or reloading, doesn't add another.
+func (c *ViperConfig) addLoader(key string, load func()) {
+	if c.loaders == nil {
+		c.loaders = map[string]func(){}
+	}
+	if _, ok := c.loaders[key]; !ok {
+		c.loaderOrder = append(c.loaderOrder, key)
+	}
+	c.loaders[key] = load
+}
+
+// reload will load each section again, so values from flags parsed after the
+// sections were first loaded are used. Errors found when the loader was created
+// are kept, errors found loading sections are found again.
+func (c *ViperConfig) reload() {
+	c.Config = &Config{
+		httpClients: map[string]HTTPClientConfig{},
+		sections:    map[string]interface{}{},
+		hooks:       c.hooks,
+	}
+	c.errs = validator.New()
+	mergeErrs(c.errs, c.initErrs)
+	c.bound = map[string]struct{}{}
+	c.envAliases = map[string][]string{}
+	c.missing = map[string]struct{}{}
+	c.required = map[string]struct{}{}
+	c.envFiles = map[string]envFile{}
+	c.resolved = map[string]string{}
+	for _, key := range c.loaderOrder {
+		c.loaders[key]()
+	}
+}
@@ -0,0 +1,223 @@
+package goconfig
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFlagSet_Precedence(t *testing.T) {
+	tests := map[string]struct {
+		env     map[string]string
+		args    []string
+		exp     Port
+		expSrcs []string
+	}{
+		"default": {
+			exp:     "8080",
+			expSrcs: []string{SourceDefault},
+		},
+		"env over default": {
+			env:     map[string]string{"SERVER_PORT": "9000"},
+			exp:     "9000",
+			expSrcs: []string{SourceEnv, SourceDefault},
+		},
+		"flag over env": {
+			env:     map[string]string{"SERVER_PORT": "9000"},
+			args:    []string{"--server-port", "9100"},
+			exp:     "9100",
+			expSrcs: []string{SourceFlag, SourceEnv, SourceDefault},
+		},
+		"flag over env file": {
+			env:     map[string]string{"SERVER_PORT_FILE": "port"},
+			args:    []string{"--server-port=:9200"},
+			exp:     "9200",
+			expSrcs: []string{SourceFlag, SourceEnv, SourceDefault},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				if k == "SERVER_PORT_FILE" {
+					v = filepath.Join(t.TempDir(), v)
+					if err := os.WriteFile(v, []byte("9000"), 0o600); err != nil {
+						t.Fatal(err)
+					}
+				}
+				t.Setenv(k, v)
+			}
+			loader := NewViperConfig("flags", WithoutFileLookup())
+			loader.WithServer()
+			if err := loader.FlagSet().Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := loader.LoadE()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != test.exp {
+				t.Fatalf("expected %s, got %s", test.exp, cfg.Server.Port)
+			}
+			var srcs []string
+			for _, s := range loader.Explain(EnvServerPort) {
+				srcs = append(srcs, s.Type)
+			}
+			if !reflect.DeepEqual(srcs, test.expSrcs) {
+				t.Fatalf("expected sources %v, got %v", test.expSrcs, srcs)
+			}
+		})
+	}
+}
+
+func TestFlagSet_Flags(t *testing.T) {
+	type payments struct {
+		Host    string        `config:"host" description:"Payments host."`
+		Timeout time.Duration `config:"timeout" default:"30s"`
+		Key     string        `config:"key" secret:"true" default:"not-shown"`
+	}
+	loader := NewViperConfig("flags", WithoutFileLookup(),
+		WithDefaults(map[string]interface{}{EnvServerPort: "9000"}))
+	loader.WithServer().WithRedis().WithHTTPClient("billing").WithSection("payments", &payments{})
+	fs := loader.FlagSet()
+	tests := map[string]struct {
+		typ   string
+		def   string
+		usage string
+	}{
+		"server-port":            {typ: "string", def: "9000", usage: "Port the web server listens on."},
+		"server-tls-enabled":     {typ: "bool", def: "false", usage: "Serve over TLS."},
+		"redis-db":               {typ: "int64", def: "0", usage: "Redis database number."},
+		"redis-password":         {typ: "string", usage: "Password for the redis server."},
+		"billing-client-timeout": {typ: "string", def: "30s", usage: "Request timeout, either a duration such as 30s or a number of seconds."},
+		"payments-host":          {typ: "string", usage: "Payments host."},
+		"payments-timeout":       {typ: "string", def: "30s"},
+		"payments-key":           {typ: "string"},
+	}
+	for name, test := range tests {
+		f := fs.Lookup(name)
+		if f == nil {
+			t.Errorf("%s: flag not found", name)
+			continue
+		}
+		if f.Value.Type() != test.typ || f.DefValue != test.def || f.Usage != test.usage {
+			t.Errorf("%s: expected %s %q %q, got %s %q %q", name, test.typ, test.def, test.usage,
+				f.Value.Type(), f.DefValue, f.Usage)
+		}
+	}
+	if fs.Lookup("db-dsn") != nil {
+		t.Error("flags should only be added for loaded sections")
+	}
+	loader.WithDb()
+	if loader.FlagSet() != fs || fs.Lookup("db-dsn") == nil {
+		t.Error("flags should be added to the same FlagSet for sections loaded later")
+	}
+}
+
+func TestFlagSet_ReloadSection(t *testing.T) {
+	type payments struct {
+		Host    string        `config:"host" required:"true"`
+		Timeout time.Duration `config:"timeout" default:"30s"`
+		Tags    []string      `config:"tags" default:"a,b"`
+		Retries int           `config:"retries"`
+	}
+	var p payments
+	loader := NewViperConfig("flags", WithoutFileLookup())
+	loader.WithSection("payments", &p)
+	if err := loader.FlagSet().Parse([]string{
+		"--payments-host", "payments", "--payments-timeout", "5s",
+		"--payments-tags", "x", "--payments-tags", "y", "--payments-retries", "3",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.LoadE(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	exp := payments{Host: "payments", Timeout: 5 * time.Second, Tags: []string{"x", "y"}, Retries: 3}
+	if !reflect.DeepEqual(p, exp) {
+		t.Fatalf("expected %+v, got %+v", exp, p)
+	}
+	cfg, err := loader.LoadE()
+	if err != nil {
+		t.Fatalf("unexpected error loading twice: %s", err)
+	}
+	if got, ok := cfg.CustomSection("payments").(*payments); !ok || got != &p {
+		t.Fatal("expected the section to be loaded into the same target")
+	}
+}
+
+func TestFlagSet_HooksSurviveReload(t *testing.T) {
+	loader := NewViperConfig("flags", WithoutFileLookup(), WithValidators(func(c *Config) error {
+		if c.Server.Port == "9999" {
+			return errors.New("option hook")
+		}
+		return nil
+	}))
+	loader.WithServer()
+	loader.AddValidator(func(c *Config) error {
+		if c.Server.Port == "9999" {
+			return errors.New("added hook")
+		}
+		return nil
+	})
+	if err := loader.FlagSet().Parse([]string{"--server-port", "9999"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := loader.LoadE()
+	if err == nil || err.Error() != "[config: option hook, added hook]" {
+		t.Fatalf("expected both hooks to fail, got %v", err)
+	}
+}
+
+func TestAddGoFlags(t *testing.T) {
+	tests := map[string]struct {
+		args       []string
+		expTLS     bool
+		expPprof   bool
+		expPort    Port
+		expChanged bool
+	}{
+		"none": {
+			expPort: "8080",
+		},
+		"bool without value": {
+			args:       []string{"-server-pprof-enabled", "-server-port", "9000"},
+			expPprof:   true,
+			expPort:    "9000",
+			expChanged: true,
+		},
+		"bool with value": {
+			args:       []string{"-server-pprof-enabled=false", "--server-tls-enabled=true", "-server-tls-cert", "cert.pem"},
+			expTLS:     true,
+			expPort:    "8080",
+			expChanged: true,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			loader := NewViperConfig("flags", WithoutFileLookup())
+			loader.WithServer()
+			fs := flag.NewFlagSet("flags", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			loader.AddGoFlags(fs)
+			if err := fs.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+			if loader.flagsChanged() != test.expChanged {
+				t.Fatalf("expected flags changed %t", test.expChanged)
+			}
+			// TLS files are checked on validate, only the loaded values are of interest here.
+			_, _ = loader.LoadE()
+			s := loader.Config.Server
+			if s.TLSEnabled != test.expTLS || s.PProfEnabled != test.expPprof || s.Port != test.expPort {
+				t.Fatalf("expected tls %t, pprof %t, port %s, got %+v", test.expTLS, test.expPprof, test.expPort, s)
+			}
+		})
+	}
+}
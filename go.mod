@@ -1,9 +1,12 @@
 module github.com/theflyingcodr/goconfig
 
-go 1.17
+go 1.18
 
 require (
+	github.com/spf13/cast v1.3.1
+	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.8.1
+	github.com/subosito/gotenv v1.2.0
 	github.com/theflyingcodr/govalidator v0.1.3
 )
 
@@ -15,10 +18,7 @@ require (
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/pelletier/go-toml v1.9.3 // indirect
 	github.com/spf13/afero v1.6.0 // indirect
-	github.com/spf13/cast v1.3.1 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/subosito/gotenv v1.2.0 // indirect
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
 	golang.org/x/text v0.3.6 // indirect
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
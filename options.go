@@ -0,0 +1,221 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ViperOption can be supplied to NewViperConfig to change
+// how configuration is located and read.
+type ViperOption func(o *viperOptions)
+
+// viperOptions contains the settings used to setup a ViperConfig.
+type viperOptions struct {
+	appName     string
+	envPrefix   string
+	envFallback bool
+	configName  string
+	configType  string
+	configFile  string
+	searchPaths []string
+	envReplacer *strings.Replacer
+	fileLookup  bool
+	defaults    map[string]interface{}
+	strict      StrictMode
+	validators  []ValidatorFunc
+	resolvers   map[string]SecretResolver
+	keyEnv      string
+	keyFile     string
+	remote      RemoteProvider
+}
+
+// defaultViperOptions returns the options used when none are supplied.
+func defaultViperOptions(appname string) *viperOptions {
+	return &viperOptions{
+		appName:     appname,
+		envFallback: true,
+		configName:  "config",
+		searchPaths: []string{
+			fmt.Sprintf("/etc/%s/", appname),
+			fmt.Sprintf("$HOME/.%s", appname),
+			".",
+		},
+		envReplacer: strings.NewReplacer(".", "_"),
+		fileLookup:  true,
+		keyEnv:      DefaultKeyEnv,
+	}
+}
+
+// WithConfigName sets the name of the config file to look for, without
+// the extension, this defaults to 'config'.
+func WithConfigName(name string) ViperOption {
+	return func(o *viperOptions) {
+		o.configName = name
+	}
+}
+
+// WithConfigType restricts the config file searched for to a single format, such
+// as 'yaml' or 'json'. By default any supported format is found and each file is
+// parsed according to its extension.
+func WithConfigType(configType string) ViperOption {
+	return func(o *viperOptions) {
+		o.configType = configType
+	}
+}
+
+// WithConfigFile will read the config file at path, parsed according to its
+// extension unless WithConfigType is used, rather than searching for config files.
+// Environment and local overlays are not read and a missing file is an error.
+func WithConfigFile(path string) ViperOption {
+	return func(o *viperOptions) {
+		o.configFile = path
+		o.fileLookup = true
+	}
+}
+
+// WithSearchPaths replaces the default config file search paths of
+// /etc/<appname>/, $HOME/.<appname> and the working directory.
+//
+// Paths are searched in the order supplied.
+func WithSearchPaths(paths ...string) ViperOption {
+	return func(o *viperOptions) {
+		o.searchPaths = paths
+	}
+}
+
+// WithEnvKeyReplacer sets the replacer used to convert config keys to
+// environment variable names, by default '.' is replaced with '_' so
+// server.port is read from SERVER_PORT. A nil replacer leaves keys unchanged,
+// so server.port is read from SERVER.PORT.
+func WithEnvKeyReplacer(r *strings.Replacer) ViperOption {
+	return func(o *viperOptions) {
+		o.envReplacer = r
+	}
+}
+
+// WithoutFileLookup will stop config files being searched for, configuration
+// will then only be read from the environment and defaults.
+func WithoutFileLookup() ViperOption {
+	return func(o *viperOptions) {
+		o.fileLookup = false
+	}
+}
+
+// WithEnvPrefix will namespace all environment variables with prefix, for
+// example a prefix of MYAPP will read server.port from MYAPP_SERVER_PORT.
+//
+// The unprefixed variable, SERVER_PORT, is still read if the prefixed one
+// isn't set, this can be disabled using WithoutEnvFallback.
+func WithEnvPrefix(prefix string) ViperOption {
+	return func(o *viperOptions) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithAppEnvPrefix will namespace all environment variables with the upper-cased
+// appname passed to NewViperConfig, an appname of my-app would read server.port
+// from MY_APP_SERVER_PORT.
+func WithAppEnvPrefix() ViperOption {
+	return func(o *viperOptions) {
+		o.envPrefix = envPrefixFromAppName(o.appName)
+	}
+}
+
+// WithoutEnvFallback will stop unprefixed environment variables being read
+// when an env prefix is set. Use this once you have migrated to prefixed names.
+func WithoutEnvFallback() ViperOption {
+	return func(o *viperOptions) {
+		o.envFallback = false
+	}
+}
+
+// WithDefaults will set default values for config keys, overriding the
+// built in defaults for each section:
+//
+//	goconfig.NewViperConfig("my-app", goconfig.WithDefaults(map[string]interface{}{
+//	    goconfig.EnvServerPort: "9000",
+//	}))
+//
+// This allows defaults to be declared once rather than set on viper directly. Keys
+// are case insensitive and also override the default tag of custom section fields.
+func WithDefaults(defaults map[string]interface{}) ViperOption {
+	return func(o *viperOptions) {
+		o.defaults = make(map[string]interface{}, len(defaults))
+		for k, val := range defaults {
+			// viper lower-cases keys, so Server.Port and server.port are the same key.
+			o.defaults[strings.ToLower(k)] = val
+		}
+	}
+}
+
+// WithStrict will report config file keys, and env vars starting with the env prefix,
+// that aren't read by any loaded section when LoadE is called, along with the closest
+// known key. This catches typos such as sever.port which would otherwise be ignored.
+//
+// StrictWarn logs each unknown key, StrictFail returns them as errors.
+func WithStrict(mode StrictMode) ViperOption {
+	return func(o *viperOptions) {
+		o.strict = mode
+	}
+}
+
+// WithValidators will add fns to the validators run when LoadE is called, this
+// is the same as calling AddValidator on the loader, see Config.AddValidator.
+func WithValidators(fns ...ValidatorFunc) ViperOption {
+	return func(o *viperOptions) {
+		o.validators = append(o.validators, fns...)
+	}
+}
+
+// WithSecretResolver will resolve config values using scheme, such as vault://secret/data/app#dsn
+// for a scheme of vault, with r when they are read. Resolved values are treated as secrets.
+//
+//	vault := goconfig.NewVaultResolver(os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN"))
+//	goconfig.NewViperConfig("my-app", goconfig.WithSecretResolver("vault", vault))
+func WithSecretResolver(scheme string, r SecretResolver) ViperOption {
+	return func(o *viperOptions) {
+		if o.resolvers == nil {
+			o.resolvers = map[string]SecretResolver{}
+		}
+		o.resolvers[strings.ToLower(scheme)] = r
+	}
+}
+
+// WithDefaultSecretResolvers will resolve file:// and env:// values, see NewFileResolver
+// and NewEnvResolver. These aren't enabled by default as values, such as a sqlite dsn,
+// could legitimately use these schemes.
+func WithDefaultSecretResolvers() ViperOption {
+	return func(o *viperOptions) {
+		WithSecretResolver("file", NewFileResolver())(o)
+		WithSecretResolver("env", NewEnvResolver())(o)
+	}
+}
+
+// WithDecryptionKeyEnv sets the env var the key used to decrypt enc: values
+// is read from, this defaults to GOCONFIG_KEY.
+func WithDecryptionKeyEnv(name string) ViperOption {
+	return func(o *viperOptions) {
+		o.keyEnv = name
+	}
+}
+
+// WithDecryptionKeyFile will read the key used to decrypt enc: values from the
+// file at path rather than an env var.
+func WithDecryptionKeyFile(path string) ViperOption {
+	return func(o *viperOptions) {
+		o.keyFile = path
+	}
+}
+
+// WithRemote will read configuration from a remote key/value store, such as Consul or
+// etcd, when the loader is created. Remote values have the lowest precedence other than
+// defaults, so are overridden by config files and env vars.
+//
+//	goconfig.NewViperConfig("my-app", goconfig.WithRemote(goconfig.NewConsulProvider("http://localhost:8500", "my-app")))
+//
+// Use WatchRemote to be notified when remote values change.
+func WithRemote(p RemoteProvider) ViperOption {
+	return func(o *viperOptions) {
+		o.remote = p
+	}
+}
@@ -0,0 +1,94 @@
+package goconfig
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithEnvKeyReplacer(t *testing.T) {
+	tests := map[string]struct {
+		replacer *strings.Replacer
+		env      string
+	}{
+		"default replacer": {
+			replacer: strings.NewReplacer(".", "_"),
+			env:      "SERVER_PORT",
+		},
+		"custom replacer": {
+			replacer: strings.NewReplacer(".", "__"),
+			env:      "SERVER__PORT",
+		},
+		"nil replacer": {
+			env: "SERVER.PORT",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(test.env, "9000")
+			cfg, err := NewViperConfig("replacer", WithoutFileLookup(), WithEnvKeyReplacer(test.replacer)).
+				WithServer().LoadE()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != "9000" {
+				t.Fatalf("expected port 9000 from %s, got %s", test.env, cfg.Server.Port)
+			}
+		})
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	type payments struct {
+		Timeout time.Duration `config:"timeout" default:"30s"`
+		Retries int           `config:"retries" default:"3"`
+	}
+	tests := map[string]struct {
+		defaults   map[string]interface{}
+		expPort    Port
+		expTimeout time.Duration
+		expRetries int
+	}{
+		"built in and tag defaults": {
+			expPort:    "8080",
+			expTimeout: 30 * time.Second,
+			expRetries: 3,
+		},
+		"override built in and tag defaults": {
+			defaults: map[string]interface{}{
+				EnvServerPort:      "9000",
+				"payments.timeout": "5s",
+			},
+			expPort:    "9000",
+			expTimeout: 5 * time.Second,
+			expRetries: 3,
+		},
+		"keys are case insensitive": {
+			defaults: map[string]interface{}{
+				"Server.Port":      "9100",
+				"PAYMENTS.RETRIES": 5,
+			},
+			expPort:    "9100",
+			expTimeout: 30 * time.Second,
+			expRetries: 5,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var p payments
+			cfg, err := NewViperConfig("defaults", WithoutFileLookup(), WithDefaults(test.defaults)).
+				WithServer().WithSection("payments", &p).LoadE()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != test.expPort {
+				t.Fatalf("expected port %s, got %s", test.expPort, cfg.Server.Port)
+			}
+			if p.Timeout != test.expTimeout || p.Retries != test.expRetries {
+				t.Fatalf("expected timeout %s and retries %d, got %+v", test.expTimeout, test.expRetries, p)
+			}
+		})
+	}
+}
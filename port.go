@@ -0,0 +1,84 @@
+package goconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// Port is a network port between 1 and 65535, such as 8080. It can
+// be supplied as either 8080 or :8080.
+type Port string
+
+// NormalisePort trims whitespace and any leading colon from val, so
+// :8080 becomes 8080. The result is not validated, see ParsePort.
+func NormalisePort(val string) Port {
+	return Port(strings.TrimPrefix(strings.TrimSpace(val), ":"))
+}
+
+// ParsePort will normalise val and return it as a Port, an error is
+// returned if it isn't a number between 1 and 65535. An empty port is
+// only accepted if allowEmpty is true.
+func ParsePort(val string, allowEmpty bool) (Port, error) {
+	p := NormalisePort(val)
+	if err := p.validate(allowEmpty)(); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// Int returns the port as an int, 0 is returned if it is empty or invalid.
+func (p Port) Int() int {
+	if p.validate(false)() != nil {
+		return 0
+	}
+	i, _ := strconv.Atoi(string(p))
+	return i
+}
+
+// String implements the stringer interface for printing.
+func (p Port) String() string {
+	return string(p)
+}
+
+// validate ensures the port is a number between 1 and 65535,
+// if allowEmpty is true an empty port is also accepted.
+func (p Port) validate(allowEmpty bool) validator.ValidationFunc {
+	return func() error {
+		if p == "" {
+			if allowEmpty {
+				return nil
+			}
+			return errors.New("port is required")
+		}
+		// Atoi accepts a sign, which isn't valid in an address.
+		if strings.TrimLeft(string(p), "0123456789") != "" {
+			return fmt.Errorf("port %q is not a number", string(p))
+		}
+		i, err := strconv.Atoi(string(p))
+		if err != nil {
+			return fmt.Errorf("port %q is out of range", string(p))
+		}
+		if i < 1 || i > 65535 {
+			return fmt.Errorf("port %d must be between 1 and 65535", i)
+		}
+		return nil
+	}
+}
+
+// joinHostPort returns host and port as an address, IPv6 hosts are
+// bracketed. If port is empty only the host is returned.
+func joinHostPort(host string, port Port) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if port == "" {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return net.JoinHostPort(host, string(port))
+}
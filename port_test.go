@@ -0,0 +1,113 @@
+package goconfig
+
+import (
+	"testing"
+)
+
+func TestParsePort(t *testing.T) {
+	tests := map[string]struct {
+		val        string
+		allowEmpty bool
+		exp        Port
+		expErr     string
+	}{
+		"number":               {val: "8080", exp: "8080"},
+		"leading colon":        {val: ":8080", exp: "8080"},
+		"whitespace":           {val: " 443 ", exp: "443"},
+		"max":                  {val: "65535", exp: "65535"},
+		"zero":                 {val: "0", expErr: "port 0 must be between 1 and 65535"},
+		"too large":            {val: "65536", expErr: "port 65536 must be between 1 and 65535"},
+		"plus sign":            {val: "+80", expErr: `port "+80" is not a number`},
+		"minus sign":           {val: "-80", expErr: `port "-80" is not a number`},
+		"name":                 {val: "http", expErr: `port "http" is not a number`},
+		"overflow":             {val: "99999999999999999999", expErr: `port "99999999999999999999" is out of range`},
+		"empty":                {val: "", expErr: "port is required"},
+		"empty allowed":        {val: "", allowEmpty: true, exp: ""},
+		"invalid when allowed": {val: "+1", allowEmpty: true, expErr: `port "+1" is not a number`},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			p, err := ParsePort(test.val, test.allowEmpty)
+			if test.expErr != "" {
+				if err == nil || err.Error() != test.expErr {
+					t.Fatalf("expected error %q, got %v", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if p != test.exp {
+				t.Fatalf("expected %q, got %q", test.exp, p)
+			}
+		})
+	}
+}
+
+func TestPort_Int(t *testing.T) {
+	tests := map[Port]int{"8080": 8080, "": 0, "+80": 0, "http": 0, "70000": 0}
+	for p, exp := range tests {
+		if i := p.Int(); i != exp {
+			t.Errorf("%q: expected %d, got %d", p, exp, i)
+		}
+	}
+}
+
+func TestAddr(t *testing.T) {
+	tests := map[string]struct {
+		host string
+		port Port
+		exp  string
+	}{
+		"host and port": {host: "example.com", port: "443", exp: "example.com:443"},
+		"no port":       {host: "example.com", exp: "example.com"},
+		"ipv6":          {host: "::1", port: "8080", exp: "[::1]:8080"},
+		"bracketed":     {host: "[::1]", port: "8080", exp: "[::1]:8080"},
+		"ipv6 no port":  {host: "::1", exp: "[::1]"},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			s := Server{Hostname: test.host, Port: test.port}
+			if addr := s.Addr(); addr != test.exp {
+				t.Fatalf("server: expected %s, got %s", test.exp, addr)
+			}
+			h := HTTPClientConfig{Host: test.host, Port: test.port}
+			if addr := h.Addr(); addr != test.exp {
+				t.Fatalf("client: expected %s, got %s", test.exp, addr)
+			}
+		})
+	}
+}
+
+func TestWithHTTPClient_Port(t *testing.T) {
+	tests := map[string]struct {
+		port   string
+		opts   []SectionOption
+		expErr bool
+	}{
+		"empty allowed": {},
+		"empty required": {
+			opts:   []SectionOption{Required(EnvHTTPClientPort)},
+			expErr: true,
+		},
+		"valid": {port: ":9000"},
+		"signed": {
+			port:   "+9000",
+			expErr: true,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("PAYMENTS_CLIENT_HOST", "payments")
+			t.Setenv("PAYMENTS_CLIENT_PORT", test.port)
+			_, err := NewViperConfig("port", WithoutFileLookup()).
+				WithHTTPClient("payments", test.opts...).LoadE()
+			if test.expErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", test.expErr, err)
+			}
+		})
+	}
+}
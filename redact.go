@@ -0,0 +1,89 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cast"
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// redacted replaces secret values in output.
+const redacted = "[REDACTED]"
+
+// minRedactLen is the shortest secret value redacted from messages about
+// other keys, shorter values would redact too much of the message.
+const minRedactLen = 4
+
+// markSecret records key as containing a secret value.
+func (c *ViperConfig) markSecret(key string) {
+	c.secrets[key] = struct{}{}
+}
+
+// markSecrets records each secret setting.
+func (c *ViperConfig) markSecrets(settings []Setting) {
+	for _, s := range settings {
+		if s.Secret {
+			c.markSecret(s.Key)
+		}
+	}
+}
+
+// IsSecret returns true if the value of key is a secret, such as
+// db.dsn, and should not be output.
+func (c *ViperConfig) IsSecret(key string) bool {
+	_, ok := c.secrets[strings.ToLower(key)]
+	return ok
+}
+
+// RedactValue returns val, or a placeholder if key is a secret
+// and val is not empty.
+func (c *ViperConfig) RedactValue(key string, val interface{}) interface{} {
+	if !c.IsSecret(key) || isEmpty(val) {
+		return val
+	}
+	return redacted
+}
+
+// redact will remove any secret values from msg, which was reported against key.
+func (c *ViperConfig) redact(key, msg string) string {
+	for k := range c.secrets {
+		val := cast.ToString(c.lookup(k))
+		if val == "" || (k != key && len(val) < minRedactLen) {
+			continue
+		}
+		msg = strings.ReplaceAll(msg, val, redacted)
+	}
+	return msg
+}
+
+// describe returns the env var, config key and source of the value
+// for key, for use in error messages.
+func (c *ViperConfig) describe(key string) string {
+	src := "none"
+	if ss := c.Explain(key); len(ss) > 0 {
+		src = strings.TrimSpace(ss[0].Type + " " + ss[0].Location)
+	}
+	return fmt.Sprintf("env var %s, key %s, source %s", c.envName(key), key, src)
+}
+
+// annotate will add where each value was set to the messages in errs and
+// redact any secrets so the error can be safely logged.
+//
+// Only keys that have been read are described, missing keys already
+// explain how to set them.
+func (c *ViperConfig) annotate(errs validator.ErrValidation) validator.ErrValidation {
+	out := validator.New()
+	for key, msgs := range errs {
+		_, bound := c.bound[key]
+		_, missing := c.missing[key]
+		for _, msg := range msgs {
+			msg = c.redact(key, msg)
+			if bound && !missing {
+				msg = fmt.Sprintf("%s (%s)", msg, c.describe(key))
+			}
+			out[key] = append(out[key], msg)
+		}
+	}
+	return out
+}
@@ -0,0 +1,334 @@
+package goconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errKeyRemote is the key used to report remote provider errors.
+const errKeyRemote = "config.remote"
+
+// RemoteProvider reads configuration from a remote key/value store.
+type RemoteProvider interface {
+	// Location describes where values are read from, it is shown by Explain.
+	Location() string
+	// Read returns every value under the provider's key prefix, keyed by config
+	// key, so my-app/server/port under a prefix of my-app is returned as server.port.
+	Read() (map[string]string, error)
+}
+
+// RemoteOption can be supplied when creating a RemoteProvider.
+type RemoteOption func(r *remoteHTTP)
+
+// WithRemoteToken sets the token used to authenticate, sent as an X-Consul-Token
+// header to Consul and an Authorization header to etcd.
+func WithRemoteToken(token string) RemoteOption {
+	return func(r *remoteHTTP) {
+		r.token = token
+	}
+}
+
+// WithRemoteHTTPClient sets the http client used to read values, by
+// default a client with a 10 second timeout is used.
+func WithRemoteHTTPClient(c *http.Client) RemoteOption {
+	return func(r *remoteHTTP) {
+		r.client = c
+	}
+}
+
+// remoteHTTP contains the settings shared by the http based providers.
+type remoteHTTP struct {
+	addr   string
+	prefix string
+	token  string
+	client *http.Client
+}
+
+// newRemoteHTTP applies opts and returns the result.
+func newRemoteHTTP(addr, prefix string, opts []RemoteOption) remoteHTTP {
+	r := remoteHTTP{
+		addr:   strings.TrimSuffix(addr, "/"),
+		prefix: prefix,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return r
+}
+
+// do sends req, returning the body if a 200 is returned.
+func (r remoteHTTP) do(req *http.Request) ([]byte, int, error) {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, resp.StatusCode, fmt.Errorf("%s returned %d", req.URL.Redacted(), resp.StatusCode)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// remoteKey converts a store key under prefix to a config key, an
+// empty string is returned for folders.
+func remoteKey(prefix, key string) string {
+	key = strings.Trim(strings.TrimPrefix(key, prefix), "/")
+	return strings.ToLower(strings.ReplaceAll(key, "/", "."))
+}
+
+// ConsulProvider reads values from the Consul KV http api.
+type ConsulProvider struct {
+	remoteHTTP
+}
+
+// NewConsulProvider returns a RemoteProvider reading every key under prefix, such as
+// my-app, from the Consul agent at addr, such as http://localhost:8500.
+func NewConsulProvider(addr, prefix string, opts ...RemoteOption) *ConsulProvider {
+	return &ConsulProvider{remoteHTTP: newRemoteHTTP(addr, strings.Trim(prefix, "/")+"/", opts)}
+}
+
+// Location implements RemoteProvider.
+func (p *ConsulProvider) Location() string {
+	return "consul " + p.addr + "/v1/kv/" + p.prefix
+}
+
+// consulPair is a key/value returned by Consul, values are base64 encoded.
+type consulPair struct {
+	Key   string
+	Value *string
+}
+
+// Read implements RemoteProvider.
+func (p *ConsulProvider) Read() (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, p.addr+"/v1/kv/"+p.prefix+"?recurse=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Consul-Token", p.token)
+	}
+	body, status, err := p.do(req)
+	if status == http.StatusNotFound {
+		// nothing has been stored under the prefix yet.
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pairs []consulPair
+	if err := json.Unmarshal(body, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to decode consul response: %w", err)
+	}
+	out := make(map[string]string, len(pairs))
+	for _, kv := range pairs {
+		key := remoteKey(p.prefix, kv.Key)
+		if key == "" || kv.Value == nil {
+			continue
+		}
+		val, err := base64.StdEncoding.DecodeString(*kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode consul value for %s: %w", kv.Key, err)
+		}
+		out[key] = string(val)
+	}
+	return out, nil
+}
+
+// EtcdProvider reads values from the etcd v3 http, grpc gateway, api.
+type EtcdProvider struct {
+	remoteHTTP
+}
+
+// NewEtcdProvider returns a RemoteProvider reading every key under prefix, such as
+// /my-app, from the etcd server at addr, such as http://localhost:2379.
+func NewEtcdProvider(addr, prefix string, opts ...RemoteOption) *EtcdProvider {
+	return &EtcdProvider{remoteHTTP: newRemoteHTTP(addr, strings.TrimSuffix(prefix, "/")+"/", opts)}
+}
+
+// Location implements RemoteProvider.
+func (p *EtcdProvider) Location() string {
+	return "etcd " + p.addr + " " + p.prefix
+}
+
+// etcdRange is an etcd range request and response, keys and values are base64 encoded.
+type etcdRange struct {
+	Key      string `json:"key,omitempty"`
+	RangeEnd string `json:"range_end,omitempty"`
+	Kvs      []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"kvs,omitempty"`
+}
+
+// Read implements RemoteProvider.
+func (p *EtcdProvider) Read() (map[string]string, error) {
+	bb, err := json.Marshal(etcdRange{
+		Key:      base64.StdEncoding.EncodeToString([]byte(p.prefix)),
+		RangeEnd: base64.StdEncoding.EncodeToString(prefixEnd(p.prefix)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.addr+"/v3/kv/range", bytes.NewReader(bb))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", p.token)
+	}
+	body, _, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp etcdRange
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	out := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		k, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode etcd key: %w", err)
+		}
+		val, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode etcd value for %s: %w", k, err)
+		}
+		if key := remoteKey(p.prefix, string(k)); key != "" {
+			out[key] = string(val)
+		}
+	}
+	return out, nil
+}
+
+// prefixEnd returns the end of the etcd range containing every key starting with prefix.
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// every byte is 0xff, read to the end of the keyspace.
+	return []byte{0}
+}
+
+// readRemote will read values from the remote provider and merge them below
+// any config files.
+func (c *ViperConfig) readRemote() {
+	vals, err := c.opts.remote.Read()
+	if err != nil {
+		c.addErr(errKeyRemote, fmt.Errorf("failed to read remote config from %s: %w", c.opts.remote.Location(), err))
+		return
+	}
+	c.setRemote(vals)
+}
+
+// setRemote will store vals as the remote values, replacing any read previously,
+// and merge them below any config files.
+func (c *ViperConfig) setRemote(vals map[string]string) {
+	c.remoteValues = vals
+	settings := make(map[string]interface{}, len(vals))
+	for k, val := range vals {
+		settings[k] = val
+	}
+	f := configFile{path: c.opts.remote.Location(), remote: true, settings: settings}
+	for i := range c.files {
+		if c.files[i].remote {
+			c.files[i] = f
+			c.addErr(errKeyRemote, c.syncConfig())
+			return
+		}
+	}
+	// remote values have the lowest precedence of any file.
+	c.files = append([]configFile{f}, c.files...)
+	c.addErr(errKeyRemote, c.syncConfig())
+}
+
+// WatchRemote will read the remote provider, added using WithRemote, every interval
+// until ctx is done. When values have been added, changed or removed they are applied
+// to the loader and fn is called with the keys that changed. It blocks, so should
+// usually be run in a goroutine:
+//
+//	go loader.WatchRemote(ctx, time.Minute, func(changed []string, err error) {
+//	    if err != nil {
+//	        return
+//	    }
+//	    cfg, err := loader.Reload()
+//	})
+//
+// Config that has already been loaded isn't changed, call Reload to use the new values.
+// The loader isn't safe for concurrent use, so while watching only use it from fn.
+// Read errors are passed to fn and polling continues.
+func (c *ViperConfig) WatchRemote(
+	ctx context.Context,
+	interval time.Duration,
+	fn func(changed []string, err error),
+) error {
+	if c.opts.remote == nil {
+		return errors.New("no remote provider has been added, use WithRemote")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0, got %s", interval)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+		vals, err := c.opts.remote.Read()
+		if err != nil {
+			fn(nil, err)
+			continue
+		}
+		// the store is readable again, a failure reading it at startup no longer applies.
+		delete(c.initErrs, errKeyRemote)
+		delete(c.errs, errKeyRemote)
+		changed := changedKeys(c.remoteValues, vals)
+		if len(changed) == 0 {
+			continue
+		}
+		for _, k := range changed {
+			// read the reference or encrypted value again when next loaded.
+			delete(c.resolved, k)
+		}
+		c.setRemote(vals)
+		fn(changed, nil)
+	}
+}
+
+// changedKeys returns the keys, sorted, that differ between a and b.
+func changedKeys(a, b map[string]string) []string {
+	var changed []string
+	for k, val := range a {
+		if bv, ok := b[k]; !ok || bv != val {
+			changed = append(changed, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,357 @@
+package goconfig
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/theflyingcodr/goconfig/remotetest"
+)
+
+// remoteBackend creates a fake store and a provider reading from it.
+type remoteBackend struct {
+	server   func() *remotetest.Server
+	provider func(addr string) RemoteProvider
+	// key returns the store key for a key under the provider's prefix, such as server/port.
+	key func(k string) string
+}
+
+var remoteBackends = map[string]remoteBackend{
+	"consul": {
+		server: remotetest.NewConsulServer,
+		provider: func(addr string) RemoteProvider {
+			return NewConsulProvider(addr, "my-app")
+		},
+		key: func(k string) string { return "my-app/" + k },
+	},
+	"etcd": {
+		server: remotetest.NewEtcdServer,
+		provider: func(addr string) RemoteProvider {
+			return NewEtcdProvider(addr, "/my-app")
+		},
+		key: func(k string) string { return "/my-app/" + k },
+	},
+}
+
+func TestRemoteProvider_Read(t *testing.T) {
+	tests := map[string]struct {
+		values map[string]string
+		other  map[string]string
+		exp    map[string]string
+	}{
+		"keys are mapped": {
+			values: map[string]string{
+				"server/port":          "9000",
+				"Payments/Client/Host": "payments",
+				"db/dsn":               "postgres://",
+			},
+			exp: map[string]string{
+				"server.port":          "9000",
+				"payments.client.host": "payments",
+				"db.dsn":               "postgres://",
+			},
+		},
+		"other prefixes are ignored": {
+			values: map[string]string{"server/port": "9000"},
+			other:  map[string]string{"my-app2/server/port": "9100", "/my-app2/server/port": "9100", "other/server/port": "9200"},
+			exp:    map[string]string{"server.port": "9000"},
+		},
+		"empty prefix": {
+			other: map[string]string{"other/server/port": "9200"},
+			exp:   map[string]string{},
+		},
+	}
+	for backendName, backend := range remoteBackends {
+		backend := backend
+		for name, test := range tests {
+			test := test
+			t.Run(backendName+" "+name, func(t *testing.T) {
+				srv := backend.server()
+				defer srv.Close()
+				for k, v := range test.values {
+					srv.Set(backend.key(k), v)
+				}
+				for k, v := range test.other {
+					srv.Set(k, v)
+				}
+				vals, err := backend.provider(srv.URL).Read()
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if !reflect.DeepEqual(vals, test.exp) {
+					t.Fatalf("expected %v, got %v", test.exp, vals)
+				}
+			})
+		}
+	}
+}
+
+func TestWithRemote_Precedence(t *testing.T) {
+	tests := map[string]struct {
+		file   string
+		env    string
+		exp    Port
+		expSrc string
+	}{
+		"remote over default": {
+			exp:    "9000",
+			expSrc: SourceRemote,
+		},
+		"file over remote": {
+			file:   "server:\n  port: 9100\n",
+			exp:    "9100",
+			expSrc: SourceFile,
+		},
+		"env over file and remote": {
+			file:   "server:\n  port: 9100\n",
+			env:    "9200",
+			exp:    "9200",
+			expSrc: SourceEnv,
+		},
+	}
+	for backendName, backend := range remoteBackends {
+		backend := backend
+		for name, test := range tests {
+			test := test
+			t.Run(backendName+" "+name, func(t *testing.T) {
+				srv := backend.server()
+				defer srv.Close()
+				srv.Set(backend.key("server/port"), "9000")
+				if test.env != "" {
+					t.Setenv("SERVER_PORT", test.env)
+				}
+				opts := []ViperOption{WithRemote(backend.provider(srv.URL)), WithoutFileLookup()}
+				if test.file != "" {
+					path := filepath.Join(t.TempDir(), "config.yaml")
+					if err := os.WriteFile(path, []byte(test.file), 0o600); err != nil {
+						t.Fatal(err)
+					}
+					opts = append(opts, WithConfigFile(path))
+				}
+				loader := NewViperConfig("my-app", opts...)
+				cfg, err := loader.WithServer().LoadE()
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if cfg.Server.Port != test.exp {
+					t.Fatalf("expected %s, got %s", test.exp, cfg.Server.Port)
+				}
+				if src := loader.Explain(EnvServerPort)[0].Type; src != test.expSrc {
+					t.Fatalf("expected source %s, got %s", test.expSrc, src)
+				}
+			})
+		}
+	}
+}
+
+func TestWithRemote_ReadError(t *testing.T) {
+	srv := remotetest.NewConsulServer()
+	srv.Close()
+	_, err := NewViperConfig("my-app", WithoutFileLookup(), WithRemote(NewConsulProvider(srv.URL, "my-app"))).
+		WithServer().LoadE()
+	if err == nil {
+		t.Fatal("expected an error reading the remote config")
+	}
+}
+
+func TestWatchRemote(t *testing.T) {
+	for backendName, backend := range remoteBackends {
+		backend := backend
+		t.Run(backendName, func(t *testing.T) {
+			srv := backend.server()
+			defer srv.Close()
+			srv.Set(backend.key("server/port"), "9100")
+			srv.Set(backend.key("server/host"), "localhost")
+			loader := NewViperConfig("my-app", WithoutFileLookup(), WithRemote(backend.provider(srv.URL)))
+			cfg, err := loader.WithServer().LoadE()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != "9100" {
+				t.Fatalf("expected 9100, got %s", cfg.Server.Port)
+			}
+
+			srv.Set(backend.key("server/port"), "9200")
+			srv.Delete(backend.key("server/host"))
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var changed []string
+			var loadErr error
+			err = loader.WatchRemote(ctx, 10*time.Millisecond, func(keys []string, err error) {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+					return
+				}
+				changed = keys
+				cfg, loadErr = loader.Reload()
+				cancel()
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+			if exp := []string{EnvServerHost, EnvServerPort}; !reflect.DeepEqual(changed, exp) {
+				t.Fatalf("expected changed keys %v, got %v", exp, changed)
+			}
+			if loadErr != nil {
+				t.Fatalf("unexpected error loading again: %s", loadErr)
+			}
+			if cfg.Server.Port != "9200" || cfg.Server.Hostname != "" {
+				t.Fatalf("expected new values to be loaded, got %+v", cfg.Server)
+			}
+			if srv.Requests() < 2 {
+				t.Fatalf("expected the store to be polled, got %d requests", srv.Requests())
+			}
+		})
+	}
+}
+
+func TestWatchRemote_Errors(t *testing.T) {
+	tests := map[string]struct {
+		opts     []ViperOption
+		interval time.Duration
+		exp      string
+	}{
+		"no provider": {
+			interval: time.Second,
+			exp:      "no remote provider has been added, use WithRemote",
+		},
+		"zero interval": {
+			opts: []ViperOption{WithRemote(NewConsulProvider("http://localhost:0", "my-app"))},
+			exp:  "interval must be greater than 0, got 0s",
+		},
+		"negative interval": {
+			opts:     []ViperOption{WithRemote(NewConsulProvider("http://localhost:0", "my-app"))},
+			interval: -time.Second,
+			exp:      "interval must be greater than 0, got -1s",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			loader := NewViperConfig("my-app", append(test.opts, WithoutFileLookup())...)
+			err := loader.WatchRemote(context.Background(), test.interval, func([]string, error) {})
+			if err == nil || err.Error() != test.exp {
+				t.Fatalf("expected error %q, got %v", test.exp, err)
+			}
+		})
+	}
+}
+
+// watchOnce will watch the remote provider until values change, returning the keys
+// that changed and the result of reloading the config.
+func watchOnce(t *testing.T, loader *ViperConfig) ([]string, *Config, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var changed []string
+	var cfg *Config
+	var loadErr error
+	err := loader.WatchRemote(ctx, 10*time.Millisecond, func(keys []string, err error) {
+		if err != nil {
+			return
+		}
+		changed = keys
+		cfg, loadErr = loader.Reload()
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	return changed, cfg, loadErr
+}
+
+func TestWatchRemote_Reload(t *testing.T) {
+	type payments struct {
+		Host    string `config:"host" required:"true"`
+		Retries int    `config:"retries" default:"1"`
+	}
+	for backendName, backend := range remoteBackends {
+		backend := backend
+		t.Run(backendName, func(t *testing.T) {
+			t.Run("missing at startup", func(t *testing.T) {
+				srv := backend.server()
+				defer srv.Close()
+				loader := NewViperConfig("my-app", WithoutFileLookup(), WithRemote(backend.provider(srv.URL)))
+				if _, err := loader.WithDb().LoadE(); err == nil {
+					t.Fatal("expected an error for the missing db values")
+				}
+
+				srv.Set(backend.key("db/type"), "postgres")
+				srv.Set(backend.key("db/dsn"), "postgres://localhost/app")
+				changed, cfg, err := watchOnce(t, loader)
+				if err != nil {
+					t.Fatalf("unexpected error reloading: %s", err)
+				}
+				if exp := []string{EnvDbDsn, EnvDb}; !reflect.DeepEqual(changed, exp) {
+					t.Fatalf("expected changed keys %v, got %v", exp, changed)
+				}
+				if cfg.Db.Type != DBPostgres || cfg.Db.Dsn != "postgres://localhost/app" {
+					t.Fatalf("expected the db values to be loaded, got %+v", cfg.Db)
+				}
+			})
+			t.Run("custom section", func(t *testing.T) {
+				srv := backend.server()
+				defer srv.Close()
+				srv.Set(backend.key("payments/host"), "old.example.com")
+				var p payments
+				loader := NewViperConfig("my-app", WithoutFileLookup(), WithRemote(backend.provider(srv.URL)))
+				if _, err := loader.WithSection("payments", &p).LoadE(); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				srv.Set(backend.key("payments/host"), "new.example.com")
+				srv.Set(backend.key("payments/retries"), "3")
+				_, cfg, err := watchOnce(t, loader)
+				if err != nil {
+					t.Fatalf("unexpected error reloading: %s", err)
+				}
+				exp := payments{Host: "new.example.com", Retries: 3}
+				if p != exp {
+					t.Fatalf("expected %+v, got %+v", exp, p)
+				}
+				if got, ok := cfg.CustomSection("payments").(*payments); !ok || got != &p {
+					t.Fatalf("expected the section to be loaded into the same target, got %v", got)
+				}
+				if exp := []string{"section:payments"}; !reflect.DeepEqual(loader.loaderOrder, exp) {
+					t.Fatalf("expected loaders %v, got %v", exp, loader.loaderOrder)
+				}
+			})
+		})
+	}
+}
+
+func TestWatchRemote_ReadErrorAtStartup(t *testing.T) {
+	store := remotetest.NewConsulServer()
+	defer store.Close()
+	store.Set("my-app/server/port", "9100")
+	var available int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&available) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		store.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	loader := NewViperConfig("my-app", WithoutFileLookup(), WithRemote(NewConsulProvider(srv.URL, "my-app")))
+	if _, err := loader.WithServer().LoadE(); err == nil {
+		t.Fatal("expected an error reading the remote config")
+	}
+
+	atomic.StoreInt32(&available, 1)
+	_, cfg, err := watchOnce(t, loader)
+	if err != nil {
+		t.Fatalf("unexpected error reloading: %s", err)
+	}
+	if cfg.Server.Port != "9100" {
+		t.Fatalf("expected 9100, got %s", cfg.Server.Port)
+	}
+}
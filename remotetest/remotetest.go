@@ -0,0 +1,167 @@
+// Package remotetest provides in-process stand-ins for the Consul KV and
+// etcd v3 http apis read by goconfig's remote providers, so code using
+// goconfig.WithRemote can be tested without a running cluster:
+//
+//	srv := remotetest.NewConsulServer()
+//	defer srv.Close()
+//	srv.Set("my-app/server/port", "9000")
+//	cfg, err := goconfig.NewViperConfig("my-app",
+//	    goconfig.WithRemote(goconfig.NewConsulProvider(srv.URL, "my-app")),
+//	).WithServer().LoadE()
+//
+// Only the requests made by the providers are supported.
+package remotetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Server is a fake key/value store served over http.
+type Server struct {
+	*httptest.Server
+	mu       sync.RWMutex
+	kv       map[string]string
+	requests int
+}
+
+// NewConsulServer returns a running Server implementing the Consul KV api.
+func NewConsulServer() *Server {
+	s := &Server{kv: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.consul))
+	return s
+}
+
+// NewEtcdServer returns a running Server implementing the etcd v3 kv range api.
+func NewEtcdServer() *Server {
+	s := &Server{kv: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.etcd))
+	return s
+}
+
+// Set stores value at key, such as my-app/server/port.
+func (s *Server) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[key] = value
+}
+
+// Delete removes key.
+func (s *Server) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.kv, key)
+}
+
+// Requests returns the number of requests served, useful when testing polling.
+func (s *Server) Requests() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.requests
+}
+
+// keys returns the stored keys that satisfy match, sorted.
+func (s *Server) keys(match func(k string) bool) []string {
+	var keys []string
+	for k := range s.kv {
+		if match(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// consulPair is a key/value as returned by Consul.
+type consulPair struct {
+	Key   string
+	Value string
+}
+
+// consul serves GET /v1/kv/<prefix>?recurse.
+func (s *Server) consul(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, "/v1/kv/") {
+		http.NotFound(w, r)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	_, recurse := r.URL.Query()["recurse"]
+	keys := s.keys(func(k string) bool {
+		if recurse {
+			return strings.HasPrefix(k, prefix)
+		}
+		return k == prefix
+	})
+	if len(keys) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	pairs := make([]consulPair, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, consulPair{Key: k, Value: base64.StdEncoding.EncodeToString([]byte(s.kv[k]))})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+// etcdKv is a key/value as returned by etcd, base64 encoded.
+type etcdKv struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// etcdRange is an etcd range request, base64 encoded.
+type etcdRange struct {
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end"`
+}
+
+// etcd serves POST /v3/kv/range.
+func (s *Server) etcd(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if r.Method != http.MethodPost || r.URL.Path != "/v3/kv/range" {
+		http.NotFound(w, r)
+		return
+	}
+	var req etcdRange
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := base64.StdEncoding.DecodeString(req.Key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := base64.StdEncoding.DecodeString(req.RangeEnd)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	keys := s.keys(func(k string) bool {
+		if len(end) == 0 {
+			return k == string(key)
+		}
+		// a range end of \x00 reads every key from key onwards.
+		return k >= string(key) && (string(end) == "\x00" || k < string(end))
+	})
+	kvs := make([]etcdKv, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, etcdKv{
+			Key:   base64.StdEncoding.EncodeToString([]byte(k)),
+			Value: base64.StdEncoding.EncodeToString([]byte(s.kv[k])),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"kvs": kvs, "count": len(kvs)})
+}
@@ -0,0 +1,89 @@
+package goconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SectionOption can be supplied to a With* call to change
+// how the section is loaded.
+type SectionOption func(o *sectionOptions)
+
+// sectionOptions contains the settings used when loading a section.
+type sectionOptions struct {
+	required []string
+}
+
+// newSectionOptions applies opts and returns the result.
+func newSectionOptions(opts []SectionOption) *sectionOptions {
+	o := &sectionOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Required marks config keys as required, if any are missing LoadE will
+// return an error naming the env var and config key to set:
+//
+//	goconfig.NewViperConfig("my-app").
+//	    WithServer(goconfig.Required(goconfig.EnvServerHost)).
+//	    WithHTTPClient("payments", goconfig.Required(goconfig.EnvHTTPClientPort))
+//
+// Http client keys can be given as the Env constant, the client name is added.
+// Some keys, such as db.dsn, are always required.
+func Required(keys ...string) SectionOption {
+	return func(o *sectionOptions) {
+		o.required = append(o.required, keys...)
+	}
+}
+
+// checkRequired will record an error for each required setting, or key, that has no value.
+func (c *ViperConfig) checkRequired(settings []Setting, keys []string) {
+	for _, s := range settings {
+		if s.Required {
+			keys = append(keys, s.Key)
+		}
+	}
+	for _, key := range keys {
+		c.required[key] = struct{}{}
+		if _, ok := c.missing[key]; ok {
+			continue
+		}
+		if isEmpty(c.get(key)) {
+			c.addMissing(key)
+		}
+	}
+}
+
+// addMissing records key as missing along with how to set it.
+func (c *ViperConfig) addMissing(key string) {
+	c.missing[key] = struct{}{}
+	if _, ok := c.flags[key]; ok {
+		c.addErr(key, fmt.Errorf("value is required, set flag --%s, env var %s or key %s in a config file",
+			flagName(key), c.envName(key), key))
+		return
+	}
+	c.addErr(key, fmt.Errorf("value is required, set env var %s or key %s in a config file", c.envName(key), key))
+}
+
+// markUnusable records err against key and leaves its value empty. The key is
+// treated as missing, so validation failures caused by the empty value aren't reported.
+func (c *ViperConfig) markUnusable(key string, err error) {
+	c.resolved[key] = ""
+	c.missing[key] = struct{}{}
+	c.addErr(key, err)
+}
+
+// formatKeys will add the http client name to any keys, such as
+// EnvHTTPClientHost, that contain a placeholder for it.
+func formatKeys(keys []string, name string) []string {
+	out := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if strings.Contains(k, "%s") {
+			k = fmt.Sprintf(k, name)
+		}
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package goconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretResolver resolves a reference to a secret, such as
+// vault://secret/data/payments#dsn, to the secret value.
+//
+// Resolvers are registered against a URI scheme using WithSecretResolver,
+// any config value using that scheme is resolved when it is read.
+type SecretResolver interface {
+	Resolve(ref *url.URL) (string, error)
+}
+
+// SecretResolverFunc allows a func to be used as a SecretResolver.
+type SecretResolverFunc func(ref *url.URL) (string, error)
+
+// Resolve implements SecretResolver by calling fn.
+func (fn SecretResolverFunc) Resolve(ref *url.URL) (string, error) {
+	return fn(ref)
+}
+
+// NewFileResolver returns a SecretResolver that reads file:// references, such
+// as file:///run/secrets/redis, returning the trimmed contents of the file.
+func NewFileResolver() SecretResolver {
+	return SecretResolverFunc(func(ref *url.URL) (string, error) {
+		path := ref.Host + ref.Path
+		if path == "" {
+			return "", errors.New("file reference must contain a path, such as file:///run/secrets/dsn")
+		}
+		bb, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(bb)), nil
+	})
+}
+
+// NewEnvResolver returns a SecretResolver that reads env:// references, such
+// as env://PAYMENTS_DSN, returning the value of the environment variable.
+func NewEnvResolver() SecretResolver {
+	return SecretResolverFunc(func(ref *url.URL) (string, error) {
+		if ref.Host == "" {
+			return "", errors.New("env reference must contain a name, such as env://PAYMENTS_DSN")
+		}
+		val, ok := os.LookupEnv(ref.Host)
+		if !ok {
+			return "", fmt.Errorf("env var %s is not set", ref.Host)
+		}
+		return val, nil
+	})
+}
+
+// resolve will replace the value of key with the secret it references, if it uses
+// the scheme of a registered SecretResolver, or decrypt it if it starts with enc:.
+// Resolved values are treated as secrets.
+//
+// If the secret can't be resolved an error is recorded and the value is left empty.
+func (c *ViperConfig) resolve(key string) {
+	if _, ok := c.resolved[key]; ok {
+		return
+	}
+	ref, ok := c.lookup(key).(string)
+	if !ok {
+		return
+	}
+	if strings.HasPrefix(ref, encPrefix) {
+		c.decrypt(key, ref)
+		return
+	}
+	idx := strings.Index(ref, "://")
+	if idx < 1 {
+		return
+	}
+	r, ok := c.opts.resolvers[strings.ToLower(ref[:idx])]
+	if !ok {
+		return
+	}
+	c.markSecret(key)
+	u, err := url.Parse(ref)
+	if err == nil {
+		c.resolved[key], err = r.Resolve(u)
+	}
+	if err != nil {
+		c.markUnusable(key, fmt.Errorf("failed to resolve %s: %w", ref, err))
+	}
+}
@@ -0,0 +1,104 @@
+package goconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resolvedSection is loaded from values that reference secrets.
+type resolvedSection struct {
+	Password string `config:"password"`
+	Host     string `config:"host"`
+}
+
+func TestResolve(t *testing.T) {
+	srv, _ := newVaultServer(t)
+	path := filepath.Join(t.TempDir(), "redis")
+	if err := os.WriteFile(path, []byte("file-password\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		env    map[string]string
+		key    string
+		exp    string
+		expErr string
+	}{
+		"file reference": {
+			env: map[string]string{"PAYMENTS_PASSWORD": "file://" + path},
+			key: "payments.password",
+			exp: "file-password",
+		},
+		"env reference": {
+			env: map[string]string{"PAYMENTS_PASSWORD": "env://TEST_REDIS_PASSWORD", "TEST_REDIS_PASSWORD": "env-password"},
+			key: "payments.password",
+			exp: "env-password",
+		},
+		"vault reference": {
+			env: map[string]string{"PAYMENTS_HOST": "vault://secret/data/payments#dsn"},
+			key: "payments.host",
+			exp: "postgres://payments",
+		},
+		"unregistered scheme": {
+			env: map[string]string{"PAYMENTS_HOST": "redis://localhost:6379"},
+			key: "payments.host",
+			exp: "redis://localhost:6379",
+		},
+		"missing file": {
+			env:    map[string]string{"PAYMENTS_PASSWORD": "file:///does/not/exist"},
+			key:    "payments.password",
+			expErr: "payments.password: failed to resolve file:///does/not/exist: open /does/not/exist: no such file or directory",
+		},
+		"missing env": {
+			env:    map[string]string{"PAYMENTS_PASSWORD": "env://TEST_MISSING"},
+			key:    "payments.password",
+			expErr: "payments.password: failed to resolve env://TEST_MISSING: env var TEST_MISSING is not set",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			loader := NewViperConfig("resolve", WithoutFileLookup(), WithDefaultSecretResolvers(),
+				WithSecretResolver("vault", NewVaultResolver(srv.URL, "token")))
+			_, err := loader.WithSection("payments", &resolvedSection{}).LoadE()
+			if test.expErr != "" {
+				if err == nil || err.Error() != "["+test.expErr+"]" {
+					t.Fatalf("expected error %q, got %v", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if val := loader.lookup(test.key); val != test.exp {
+				t.Fatalf("expected %s, got %v", test.exp, val)
+			}
+			if test.exp != test.env[strings.ToUpper(strings.ReplaceAll(test.key, ".", "_"))] {
+				// resolved values are secrets.
+				if val := loader.Values()[test.key]; val != redacted {
+					t.Fatalf("expected value to be redacted, got %v", val)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve_RedactedInErrors(t *testing.T) {
+	srv, _ := newVaultServer(t)
+	t.Setenv("SERVER_PORT", "vault://secret/data/payments#dsn")
+	_, err := NewViperConfig("resolve", WithoutFileLookup(),
+		WithSecretResolver("vault", NewVaultResolver(srv.URL, "token"))).WithServer().LoadE()
+	if err == nil {
+		t.Fatal("expected an invalid port error")
+	}
+	if strings.Contains(err.Error(), "postgres://payments") {
+		t.Fatalf("resolved value should be redacted, got %s", err)
+	}
+	if !strings.Contains(err.Error(), `port "[REDACTED]" is not a number`) {
+		t.Fatalf("expected redacted port error, got %s", err)
+	}
+}
@@ -0,0 +1,277 @@
+package goconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cast"
+)
+
+// schemaDraft is the JSON Schema version generated.
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaDuration matches a duration such as 1m30s.
+const schemaDuration = `^(-?[0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// SchemaOption can be supplied when generating a JSON Schema.
+type SchemaOption func(o *schemaOptions)
+
+// schemaOptions contains the settings used when generating a JSON Schema.
+type schemaOptions struct {
+	required bool
+}
+
+// WithSchemaRequired will list required keys as required in the schema. Only use this
+// if config files must contain every required key, as keys such as db.dsn are usually
+// supplied as env vars, *_FILE env vars or secret references instead.
+func WithSchemaRequired() SchemaOption {
+	return func(o *schemaOptions) {
+		o.required = true
+	}
+}
+
+// JSONSchema returns a JSON Schema describing the config file for the app, this
+// can be used by editors to autocomplete config files and in CI to validate them.
+//
+// Every built in section is included along with the http clients and custom sections
+// that have been added to the loader, so it should be called after each With* call.
+// The schema includes the type, default, allowed values, such as the registered db
+// types, and description of each key. Required keys aren't marked as required unless
+// WithSchemaRequired is passed, as they may be supplied from outside the config file.
+func (c *ViperConfig) JSONSchema(opts ...SchemaOption) ([]byte, error) {
+	o := &schemaOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	root := schemaObject()
+	root["$schema"] = schemaDraft
+	root["title"] = c.opts.appName + " configuration"
+	settings := builtinSettings()
+	for _, name := range sortedKeys(c.httpClients) {
+		settings = append(settings, httpClientSettings(name))
+	}
+	enums := schemaEnums()
+	for _, ss := range settings {
+		for _, s := range ss {
+			t := s.Type
+			if t == nil && s.Default != nil {
+				t = reflect.TypeOf(s.Default)
+			}
+			prop := schemaForType(t)
+			if s.Description != "" {
+				prop["description"] = s.Description
+			}
+			def := s.Default
+			if d, ok := c.defaults[s.Key]; ok {
+				// overridden using WithDefaults.
+				def = d
+			}
+			if val := schemaDefault(t, def); val != nil {
+				prop["default"] = val
+			}
+			if enum, ok := enums[s.Key]; ok {
+				prop["enum"] = enum
+			}
+			if s.Key == EnvEnvironment {
+				// environments are normalised, so any case is accepted.
+				prop["pattern"] = caseInsensitivePattern(environmentNames())
+				prop["examples"] = environmentNames()
+			}
+			_, required := c.required[s.Key]
+			addSchemaProperty(root, s.Key, prop, o.required && (s.Required || required))
+		}
+	}
+	for _, name := range sortedKeys(c.sections) {
+		c.addSchemaStruct(root, name, reflect.TypeOf(c.sections[name]).Elem(), o.required)
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// addSchemaStruct will add a property for each field of the struct t, required
+// keys are only marked as such if markRequired is true.
+func (c *ViperConfig) addSchemaStruct(root map[string]interface{}, prefix string, t reflect.Type, markRequired bool) {
+	walkStruct(prefix, t, func(key string, sf reflect.StructField) {
+		prop := schemaForType(sf.Type)
+		if desc := sf.Tag.Get(tagDescription); desc != "" {
+			prop["description"] = desc
+		}
+		// the default tag, unless overridden using WithDefaults.
+		if def, ok := c.defaults[key]; ok {
+			if val := schemaDefault(sf.Type, def); val != nil {
+				prop["default"] = val
+			}
+		}
+		_, required := c.required[key]
+		addSchemaProperty(root, key, prop, markRequired && required)
+	})
+}
+
+// addSchemaProperty will add prop to root at the dotted key, creating
+// objects for each part of the key as required.
+func addSchemaProperty(root map[string]interface{}, key string, prop map[string]interface{}, required bool) {
+	parts := strings.Split(key, ".")
+	obj := root
+	for _, p := range parts[:len(parts)-1] {
+		props := obj["properties"].(map[string]interface{})
+		next, ok := props[p].(map[string]interface{})
+		if !ok || next["type"] != "object" {
+			next = schemaObject()
+			props[p] = next
+		}
+		obj = next
+	}
+	leaf := parts[len(parts)-1]
+	obj["properties"].(map[string]interface{})[leaf] = prop
+	if !required {
+		return
+	}
+	req, _ := obj["required"].([]string)
+	for _, r := range req {
+		if r == leaf {
+			return
+		}
+	}
+	req = append(req, leaf)
+	sort.Strings(req)
+	obj["required"] = req
+}
+
+// schemaObject returns an empty object schema.
+func schemaObject() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{},
+		"additionalProperties": false,
+	}
+}
+
+// schemaForType returns the schema for a value loaded as type t, nil is treated as a string.
+func schemaForType(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == nil:
+		return map[string]interface{}{"type": "string"}
+	case t == typePort:
+		return map[string]interface{}{
+			"type":    []string{"integer", "string"},
+			"minimum": 1,
+			"maximum": 65535,
+			"pattern": "^:?[0-9]+$",
+		}
+	case t == typeDuration:
+		return map[string]interface{}{
+			"type":        []string{"string", "integer"},
+			"pattern":     schemaDuration,
+			"description": "A duration such as 30s.",
+		}
+	case t == typeTime:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	// nolint:exhaustive // only supporting common config types
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		// a comma separated string is also accepted.
+		return map[string]interface{}{
+			"type":  []string{"array", "string"},
+			"items": map[string]interface{}{"type": "string"},
+		}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// schemaDefault converts the default val for a value of type t to the value
+// used in the schema, nil is returned if there is no default to show.
+func schemaDefault(t reflect.Type, val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil, time.Time:
+		// time defaults, such as env.builddate, are set at startup.
+		return nil
+	case time.Duration:
+		return v.String()
+	case string:
+		if t == nil {
+			return v
+		}
+		return schemaDefaultString(t, v)
+	}
+	return val
+}
+
+// schemaDefaultString converts a default supplied as a string, such as
+// from a struct tag, to the type t. If it can't be converted it is returned as is.
+func schemaDefaultString(t reflect.Type, val string) interface{} {
+	var out interface{}
+	var err error
+	// nolint:exhaustive // only supporting common config types
+	switch t.Kind() {
+	case reflect.Bool:
+		out, err = cast.ToBoolE(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == typeDuration {
+			return val
+		}
+		out, err = cast.ToInt64E(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out, err = cast.ToUint64E(val)
+	case reflect.Float32, reflect.Float64:
+		out, err = cast.ToFloat64E(val)
+	default:
+		return val
+	}
+	if err != nil {
+		return val
+	}
+	return out
+}
+
+// schemaEnums returns the allowed values for keys that only accept a known set.
+func schemaEnums() map[string][]string {
+	types := make([]string, 0)
+	for _, t := range DbTypes() {
+		types = append(types, string(t))
+	}
+	return map[string][]string{
+		EnvDb:       types,
+		EnvLogLevel: logLevels,
+	}
+}
+
+// environmentNames returns each environment and alias accepted by NormaliseEnvironment.
+func environmentNames() []string {
+	envs := make([]string, 0, len(environments)+len(environmentAliases))
+	for _, e := range environments {
+		envs = append(envs, string(e))
+	}
+	return append(envs, sortedKeys(environmentAliases)...)
+}
+
+// caseInsensitivePattern returns a pattern matching any of values in any case, JSON
+// Schema patterns don't support flags so each letter is matched by a character class.
+func caseInsensitivePattern(values []string) string {
+	alts := make([]string, 0, len(values))
+	for _, val := range values {
+		var sb strings.Builder
+		for _, r := range val {
+			lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+			if lower == upper {
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+				continue
+			}
+			sb.WriteString("[" + string(lower) + string(upper) + "]")
+		}
+		alts = append(alts, sb.String())
+	}
+	return "^(" + strings.Join(alts, "|") + ")$"
+}
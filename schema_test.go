@@ -0,0 +1,87 @@
+package goconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// schemaNode is the part of a JSON Schema checked by the tests.
+type schemaNode struct {
+	Properties map[string]*schemaNode `json:"properties"`
+	Required   []string               `json:"required"`
+	Pattern    string                 `json:"pattern"`
+	Enum       []string               `json:"enum"`
+}
+
+func TestJSONSchema_Required(t *testing.T) {
+	type payments struct {
+		Host string `config:"host" required:"true"`
+	}
+	tests := map[string]struct {
+		opts        []SchemaOption
+		expDb       []string
+		expClient   []string
+		expPayments []string
+	}{
+		"not required by default": {},
+		"WithSchemaRequired": {
+			opts:        []SchemaOption{WithSchemaRequired()},
+			expDb:       []string{"dsn", "type"},
+			expClient:   []string{"host"},
+			expPayments: []string{"host"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			loader := NewViperConfig("schema", WithoutFileLookup())
+			loader.WithDb().WithHTTPClient("billing").WithSection("payments", &payments{})
+			bb, err := loader.JSONSchema(test.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var root schemaNode
+			if err := json.Unmarshal(bb, &root); err != nil {
+				t.Fatal(err)
+			}
+			if got := root.Properties["db"].Required; !reflect.DeepEqual(got, test.expDb) {
+				t.Errorf("db: expected %v, got %v", test.expDb, got)
+			}
+			if got := root.Properties["billing"].Properties["client"].Required; !reflect.DeepEqual(got, test.expClient) {
+				t.Errorf("client: expected %v, got %v", test.expClient, got)
+			}
+			if got := root.Properties["payments"].Required; !reflect.DeepEqual(got, test.expPayments) {
+				t.Errorf("payments: expected %v, got %v", test.expPayments, got)
+			}
+		})
+	}
+}
+
+func TestJSONSchema_Environment(t *testing.T) {
+	bb, err := NewViperConfig("schema", WithoutFileLookup()).JSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var root schemaNode
+	if err := json.Unmarshal(bb, &root); err != nil {
+		t.Fatal(err)
+	}
+	env := root.Properties["env"].Properties["environment"]
+	if env.Enum != nil {
+		t.Fatalf("expected no enum, got %v", env.Enum)
+	}
+	re := regexp.MustCompile(env.Pattern)
+	for val, exp := range map[string]bool{
+		"dev": true, "PROD": true, "Production": true, "Live": true, "stage": true,
+		"qa": false, "prod2": false, "xdev": false,
+	} {
+		if re.MatchString(val) != exp {
+			t.Errorf("%s: expected match %t", val, exp)
+		}
+		if _, err := ParseEnvironment(val); (err == nil) != exp {
+			t.Errorf("%s: pattern and ParseEnvironment disagree", val)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package goconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Struct tags read by WithSection.
+const (
+	tagConfig   = "config"
+	tagDefault  = "default"
+	tagEnv      = "env"
+	tagRequired = "required"
+	tagSecret   = "secret"
+	// tagDescription is only used when generating a schema or flags.
+	tagDescription = "description"
+)
+
+var (
+	typeDuration = reflect.TypeOf(time.Duration(0))
+	typeTime     = reflect.TypeOf(time.Time{})
+	typePort     = reflect.TypeOf(Port(""))
+)
+
+// WithSection will load a custom section of configuration into target, which
+// must be a pointer to a struct. The section can then be retrieved by calling
+// Config.CustomSection(name).
+//
+// Each field is read from the key '<name>.<config tag>', if the config tag is missing
+// the lower-cased field name is used and a tag of '-' will skip the field.
+// Nested structs have their keys prefixed in the same way.
+//
+//	type PaymentsConfig struct {
+//	    Host    string        `config:"host" required:"true"`
+//	    Timeout time.Duration `config:"timeout" default:"30s"`
+//	    APIKey  string        `config:"apikey" env:"PAYMENTS_API_KEY"`
+//	}
+//
+// With a name of payments, Host would be read from payments.host or PAYMENTS_HOST,
+// the env tag binds an additional environment variable to the key and a secret tag
+// of "true" redacts the value from errors. A description tag is included in the
+// schema returned by JSONSchema and used as the usage text of the field's flag.
+// Keys can also be marked as required by passing the Required option with the
+// full key, payments.host.
+func (c *ViperConfig) WithSection(name string, target interface{}, opts ...SectionOption) ConfigurationLoader {
+	if _, ok := c.sections[name]; ok {
+		// the section loaded first is kept by reload, so keep the error too.
+		err := fmt.Errorf("section %s has already been loaded", name)
+		c.addErr(name, err)
+		c.initErrs[name] = append(c.initErrs[name], err.Error())
+		return c
+	}
+	c.addLoader("section:"+name, func() { c.WithSection(name, target, opts...) })
+	val := reflect.ValueOf(target)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		c.addErr(name, fmt.Errorf("section target must be a pointer to a struct, got %T", target))
+		return c
+	}
+	c.loadStruct(name, val.Elem())
+	c.sections[name] = target
+	c.checkRequired(nil, newSectionOptions(opts).required)
+	return c
+}
+
+// loadStruct will read each field of the struct val from config keys prefixed by prefix.
+func (c *ViperConfig) loadStruct(prefix string, val reflect.Value) {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := val.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		name := sf.Tag.Get(tagConfig)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		key := prefix + "." + name
+		if fv.Kind() == reflect.Struct && fv.Type() != typeTime {
+			c.loadStruct(key, fv)
+			continue
+		}
+		if def, ok := sf.Tag.Lookup(tagDefault); ok {
+			if _, set := c.defaults[key]; !set {
+				// defaults supplied using WithDefaults take precedence.
+				c.setDefault(key, def)
+			}
+		}
+		if env := sf.Tag.Get(tagEnv); env != "" {
+			c.bindEnvAlias(key, env)
+		}
+		if sf.Tag.Get(tagSecret) == "true" {
+			c.markSecret(key)
+		}
+		if sf.Tag.Get(tagRequired) == "true" {
+			c.required[key] = struct{}{}
+			if isEmpty(c.get(key)) {
+				c.addMissing(key)
+				continue
+			}
+		}
+		c.setField(key, fv)
+	}
+}
+
+// walkStruct will call fn with the key and field of each exported field of the
+// struct t, keyed in the same way as loadStruct.
+func walkStruct(prefix string, t reflect.Type, fn func(key string, sf reflect.StructField)) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			// unexported
+			continue
+		}
+		name := sf.Tag.Get(tagConfig)
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		key := prefix + "." + name
+		if sf.Type.Kind() == reflect.Struct && sf.Type != typeTime {
+			walkStruct(key, sf.Type, fn)
+			continue
+		}
+		fn(key, sf)
+	}
+}
+
+// setField will read key and store it in fv, converting it to the field type.
+func (c *ViperConfig) setField(key string, fv reflect.Value) {
+	// nolint:exhaustive // only supporting common config types
+	switch fv.Kind() {
+	case reflect.String:
+		if fv.Type() == typePort {
+			fv.SetString(string(NormalisePort(c.getString(key))))
+			return
+		}
+		fv.SetString(c.getString(key))
+	case reflect.Bool:
+		fv.SetBool(c.getBool(key))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if fv.Type() == typeDuration {
+			i = int64(c.getDuration(key))
+		} else {
+			i = c.getInt64(key)
+		}
+		if fv.OverflowInt(i) {
+			c.addErr(key, fmt.Errorf("value %d overflows %s", i, fv.Type()))
+			return
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i := c.getUint64(key)
+		if fv.OverflowUint(i) {
+			c.addErr(key, fmt.Errorf("value %d overflows %s", i, fv.Type()))
+			return
+		}
+		fv.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		fv.SetFloat(c.getFloat64(key))
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			c.addErr(key, fmt.Errorf("unsupported field type %s", fv.Type()))
+			return
+		}
+		ss := c.getStringSlice(key)
+		out := reflect.MakeSlice(fv.Type(), len(ss), len(ss))
+		for i, s := range ss {
+			out.Index(i).SetString(s)
+		}
+		fv.Set(out)
+	case reflect.Struct:
+		fv.Set(reflect.ValueOf(c.getTime(key)))
+	default:
+		c.addErr(key, fmt.Errorf("unsupported field type %s", fv.Type()))
+	}
+}
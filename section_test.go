@@ -0,0 +1,72 @@
+package goconfig
+
+import (
+	"testing"
+)
+
+func TestWithSection_EnvOrder(t *testing.T) {
+	type payments struct {
+		APIKey string `config:"apikey" env:"PAYMENTS_KEY"`
+	}
+	tests := map[string]struct {
+		env    map[string]string
+		opts   []ViperOption
+		expVal string
+		expSrc string
+	}{
+		"prefixed before alias": {
+			env: map[string]string{
+				"ORDER_PAYMENTS_APIKEY": "prefixed",
+				"PAYMENTS_KEY":          "alias",
+			},
+			opts:   []ViperOption{WithEnvPrefix("order")},
+			expVal: "prefixed",
+			expSrc: "ORDER_PAYMENTS_APIKEY",
+		},
+		"unprefixed before alias": {
+			env: map[string]string{
+				"PAYMENTS_APIKEY": "unprefixed",
+				"PAYMENTS_KEY":    "alias",
+			},
+			opts:   []ViperOption{WithEnvPrefix("order")},
+			expVal: "unprefixed",
+			expSrc: "PAYMENTS_APIKEY",
+		},
+		"alias without fallback": {
+			env: map[string]string{
+				"PAYMENTS_APIKEY": "unprefixed",
+				"PAYMENTS_KEY":    "alias",
+			},
+			opts:   []ViperOption{WithEnvPrefix("order"), WithoutEnvFallback()},
+			expVal: "alias",
+			expSrc: "PAYMENTS_KEY",
+		},
+		"alias without prefix": {
+			env: map[string]string{
+				"PAYMENTS_KEY": "alias",
+			},
+			expVal: "alias",
+			expSrc: "PAYMENTS_KEY",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			var p payments
+			loader := NewViperConfig("order", append(test.opts, WithoutFileLookup())...)
+			if _, err := loader.WithSection("payments", &p).LoadE(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if p.APIKey != test.expVal {
+				t.Fatalf("expected %s, got %s", test.expVal, p.APIKey)
+			}
+			sources := loader.Explain("payments.apikey")
+			if len(sources) == 0 || sources[0].Location != test.expSrc || sources[0].Value != test.expVal {
+				t.Fatalf("expected %s to be explained first, got %v", test.expSrc, sources)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package goconfig
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// StrictMode controls how config keys and env vars that aren't read
+// by any loaded section are reported.
+type StrictMode int
+
+// Strict modes, StrictOff is the default.
+const (
+	// StrictOff ignores unknown keys.
+	StrictOff StrictMode = iota
+	// StrictWarn logs each unknown key when LoadE is called.
+	StrictWarn
+	// StrictFail returns an error from LoadE for each unknown key.
+	StrictFail
+)
+
+// maxSuggestDistance is the largest edit distance between an unknown key
+// and a known key for the known key to be suggested.
+const maxSuggestDistance = 3
+
+// UnknownKey is a config file key, or env var, that no loaded section reads.
+type UnknownKey struct {
+	// Key is the config file key or env var name.
+	Key string
+	// Source is where the key was found, one of SourceEnv, SourceFile or SourceRemote.
+	Source string
+	// Location is the file path for file keys or the provider location for remote keys.
+	Location string
+	// Suggestion is the closest known key, if one is similar, otherwise empty.
+	Suggestion string
+}
+
+// String implements the stringer interface for printing.
+func (u UnknownKey) String() string {
+	var msg string
+	switch u.Source {
+	case SourceEnv:
+		msg = fmt.Sprintf("unknown env var %s", u.Key)
+	case SourceRemote:
+		msg = fmt.Sprintf("unknown key %s in %s", u.Key, u.Location)
+	default:
+		msg = fmt.Sprintf("unknown key %s in config file %s", u.Key, u.Location)
+	}
+	if u.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %s?", u.Suggestion)
+	}
+	return msg
+}
+
+// UnknownKeys returns each config file key, and env var starting with the
+// env prefix, that hasn't been read by a loaded section. It should be
+// called after every section has been loaded.
+//
+// Env vars are only checked when an env prefix is set, as otherwise there
+// is no way to tell which env vars are meant for the app.
+func (c *ViperConfig) UnknownKeys() []UnknownKey {
+	known := map[string]struct{}{
+		// used to select the config overlay whether or not WithEnvironment is called.
+		EnvEnvironment: {},
+	}
+	for k := range c.bound {
+		known[k] = struct{}{}
+	}
+	knownEnv := c.knownEnv(known)
+	// suggestions also include the keys of built in sections that haven't been
+	// loaded, so a typo such as redis.adress is caught before redis is loaded.
+	candidates := map[string]struct{}{}
+	for k := range known {
+		candidates[k] = struct{}{}
+	}
+	for _, ss := range builtinSettings() {
+		for _, s := range ss {
+			candidates[s.Key] = struct{}{}
+		}
+	}
+	candidateEnv := c.knownEnv(candidates)
+	var out []UnknownKey
+	for _, f := range c.files {
+		for _, k := range sortedKeys(f.settings) {
+			if f.dotenv {
+				if _, ok := knownEnv[k]; ok {
+					continue
+				}
+				out = append(out, UnknownKey{
+					Key: k, Source: SourceFile, Location: f.path, Suggestion: suggest(k, candidateEnv),
+				})
+				continue
+			}
+			if _, ok := known[k]; ok {
+				continue
+			}
+			out = append(out, UnknownKey{Key: k, Source: f.sourceType(), Location: f.path, Suggestion: suggest(k, candidates)})
+		}
+	}
+	if c.opts.envPrefix == "" {
+		return out
+	}
+	prefix := c.replaceEnv(strings.ToUpper(c.opts.envPrefix + "_"))
+	var envs []string
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, ok := knownEnv[name]; !ok {
+			envs = append(envs, name)
+		}
+	}
+	sort.Strings(envs)
+	for _, name := range envs {
+		out = append(out, UnknownKey{Key: name, Source: SourceEnv, Suggestion: suggest(name, candidateEnv)})
+	}
+	return out
+}
+
+// knownEnv returns every env var name, including *_FILE variants, that keys are read from.
+func (c *ViperConfig) knownEnv(keys map[string]struct{}) map[string]struct{} {
+	out := map[string]struct{}{}
+	for k := range keys {
+		for _, name := range c.envNames(k) {
+			out[name] = struct{}{}
+			out[name+envFileSuffix] = struct{}{}
+		}
+	}
+	return out
+}
+
+// checkUnknown will report unknown keys according to the strict mode, errors
+// are added to errs rather than the loader so each call to LoadE reports them once.
+func (c *ViperConfig) checkUnknown(errs validator.ErrValidation) {
+	if c.opts.strict == StrictOff {
+		return
+	}
+	for _, u := range c.UnknownKeys() {
+		if c.opts.strict == StrictWarn {
+			log.Printf("goconfig: %s", u)
+			continue
+		}
+		errs[u.Key] = append(errs[u.Key], u.String())
+	}
+}
+
+// suggest returns the known key closest to key, an empty string is
+// returned if none are similar enough to be a likely typo.
+func suggest(key string, known map[string]struct{}) string {
+	best, bestDist := "", maxSuggestDistance+1
+	for _, k := range sortedKeys(known) {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// minInt returns the smallest of ii.
+func minInt(ii ...int) int {
+	m := ii[0]
+	for _, i := range ii[1:] {
+		if i < m {
+			m = i
+		}
+	}
+	return m
+}
@@ -0,0 +1,82 @@
+package goconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	tests := map[string]struct {
+		file string
+		env  map[string]string
+		exp  []UnknownKey
+	}{
+		"known keys": {
+			file: "server:\n  port: 9000\n",
+		},
+		"typo of loaded section": {
+			file: "sever:\n  port: 9000\n",
+			exp:  []UnknownKey{{Key: "sever.port", Source: SourceFile, Suggestion: EnvServerPort}},
+		},
+		"typo of section not loaded": {
+			file: "redis:\n  adress: localhost:6379\n",
+			exp:  []UnknownKey{{Key: "redis.adress", Source: SourceFile, Suggestion: EnvRedisAddress}},
+		},
+		"no similar key": {
+			file: "payments:\n  host: localhost\n",
+			exp:  []UnknownKey{{Key: "payments.host", Source: SourceFile}},
+		},
+		"prefixed env var": {
+			env: map[string]string{"STRICT_SERVER_PROT": "9000"},
+			exp: []UnknownKey{{Key: "STRICT_SERVER_PROT", Source: SourceEnv, Suggestion: "STRICT_SERVER_PORT"}},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			for k, v := range test.env {
+				t.Setenv(k, v)
+			}
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(test.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			loader := NewViperConfig("strict", WithConfigFile(path), WithEnvPrefix("strict"))
+			loader.WithServer()
+			unknown := loader.UnknownKeys()
+			for i := range unknown {
+				if unknown[i].Source == SourceFile {
+					unknown[i].Location = ""
+				}
+			}
+			if !reflect.DeepEqual(unknown, test.exp) {
+				t.Fatalf("expected %v, got %v", test.exp, unknown)
+			}
+		})
+	}
+}
+
+func TestWithStrict_LoadETwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("sever:\n  port: 9000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewViperConfig("strict", WithConfigFile(path), WithStrict(StrictFail))
+	loader.WithServer()
+	for i := 0; i < 2; i++ {
+		_, err := loader.LoadE()
+		var errs validator.ErrValidation
+		if !errors.As(err, &errs) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+		exp := "unknown key sever.port in config file " + path + ", did you mean server.port?"
+		if !reflect.DeepEqual(errs["sever.port"], []string{exp}) {
+			t.Fatalf("load %d: expected %q once, got %v", i+1, exp, errs["sever.port"])
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package goconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// Validator is implemented by each section, it checks the section values
+// and adds any failures to v keyed by config key.
+//
+// Custom sections added using WithSection can also implement this, they
+// will then be validated along with the built in sections.
+type Validator interface {
+	Validate(v validator.ErrValidation) validator.ErrValidation
+}
+
+// oneOf ensures val is one of the allowed values.
+func oneOf(val string, allowed ...string) validator.ValidationFunc {
+	return func() error {
+		for _, a := range allowed {
+			if val == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown value %q, expected one of %s", val, strings.Join(allowed, ", "))
+	}
+}
+
+// validHostPort ensures val is an address in the form host:port.
+func validHostPort(val string) validator.ValidationFunc {
+	return func() error {
+		_, port, err := net.SplitHostPort(val)
+		if err != nil {
+			return fmt.Errorf("address %q must be in the form host:port", val)
+		}
+		return Port(port).validate(false)()
+	}
+}
+
+// notNegative ensures a duration, val, is zero or more.
+func notNegative(val time.Duration) validator.ValidationFunc {
+	return func() error {
+		if val < 0 {
+			return fmt.Errorf("duration %s cannot be negative", val)
+		}
+		return nil
+	}
+}
+
+// noPath ensures a host, val, doesn't contain a scheme or path.
+func noPath(val string) validator.ValidationFunc {
+	return func() error {
+		if strings.Contains(val, "/") {
+			return fmt.Errorf("host %q must not contain a scheme or path", val)
+		}
+		return nil
+	}
+}
+
+// sortedKeys returns the keys of m sorted, so validation is run in a stable order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validFile ensures path is set and is a readable file.
+func validFile(path string) validator.ValidationFunc {
+	return func() error {
+		if path == "" {
+			return errors.New("path is required when tls is enabled")
+		}
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return fmt.Errorf("file cannot be read: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		fi, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("file cannot be read: %w", err)
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("path %s is a directory, expected a file", path)
+		}
+		return nil
+	}
+}
+
+// validKeyPair ensures the cert and key are a matching pair and the
+// cert is currently valid.
+func validKeyPair(certPath, keyPath string) validator.ValidationFunc {
+	return func() error {
+		pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return fmt.Errorf("invalid tls cert and key pair: %w", err)
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("invalid tls cert: %w", err)
+		}
+		now := time.Now()
+		if now.After(cert.NotAfter) {
+			return fmt.Errorf("tls cert expired at %s", cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("tls cert is not valid until %s", cert.NotBefore.UTC().Format(time.RFC3339))
+		}
+		return nil
+	}
+}
+
+// validCA ensures the CA at path can be used to verify connections, if
+// path is empty the system CAs must be available.
+func validCA(path string) validator.ValidationFunc {
+	return func() error {
+		if path == "" {
+			if _, err := x509.SystemCertPool(); err != nil {
+				return fmt.Errorf("no ca set and system CAs cannot be loaded: %w", err)
+			}
+			return nil
+		}
+		bb, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return fmt.Errorf("ca cannot be read: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(bb) {
+			return fmt.Errorf("ca %s contains no PEM encoded certificates", path)
+		}
+		return nil
+	}
+}
+
+// validSchemaDir ensures path is a directory containing .sql migration files.
+func validSchemaDir(path string) validator.ValidationFunc {
+	return func() error {
+		if path == "" {
+			return errors.New("path is required when migrations are enabled")
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return fmt.Errorf("schema directory cannot be read: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && filepath.Ext(e.Name()) == ".sql" {
+				return nil
+			}
+		}
+		return fmt.Errorf("schema directory %s contains no .sql migration files", path)
+	}
+}
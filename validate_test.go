@@ -0,0 +1,260 @@
+package goconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	validator "github.com/theflyingcodr/govalidator"
+)
+
+// writeCert writes a self-signed cert valid between notBefore and notAfter, and
+// its key, to dir returning the paths to each.
+func writeCert(t *testing.T, dir, name string, notBefore, notAfter time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+// checkErr fails the test if err doesn't contain exp, or isn't nil when exp is empty.
+func checkErr(t *testing.T, err error, exp string) {
+	t.Helper()
+	if exp == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), exp) {
+		t.Fatalf("expected error containing %q, got %v", exp, err)
+	}
+}
+
+func TestValidFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"cert.pem": "cert"})
+	tests := map[string]struct {
+		path   string
+		expErr string
+	}{
+		"readable file": {
+			path: filepath.Join(dir, "cert.pem"),
+		},
+		"empty path": {
+			expErr: "path is required when tls is enabled",
+		},
+		"missing file": {
+			path:   filepath.Join(dir, "missing.pem"),
+			expErr: "file cannot be read",
+		},
+		"directory": {
+			path:   dir,
+			expErr: "is a directory, expected a file",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			checkErr(t, validFile(test.path)(), test.expErr)
+		})
+	}
+}
+
+func TestValidKeyPair(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	validCert, validKey := writeCert(t, dir, "valid", now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCert, expiredKey := writeCert(t, dir, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	futureCert, futureKey := writeCert(t, dir, "future", now.Add(time.Hour), now.Add(2*time.Hour))
+	tests := map[string]struct {
+		cert   string
+		key    string
+		expErr string
+	}{
+		"valid pair": {
+			cert: validCert,
+			key:  validKey,
+		},
+		"expired cert": {
+			cert:   expiredCert,
+			key:    expiredKey,
+			expErr: "tls cert expired at",
+		},
+		"not yet valid cert": {
+			cert:   futureCert,
+			key:    futureKey,
+			expErr: "tls cert is not valid until",
+		},
+		"mismatched key": {
+			cert:   validCert,
+			key:    expiredKey,
+			expErr: "invalid tls cert and key pair",
+		},
+		"key as cert": {
+			cert:   validKey,
+			key:    validKey,
+			expErr: "invalid tls cert and key pair",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			checkErr(t, validKeyPair(test.cert, test.key)(), test.expErr)
+		})
+	}
+}
+
+func TestValidCA(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"empty.pem": "", "garbage.pem": "not a cert"})
+	now := time.Now()
+	ca, key := writeCert(t, dir, "ca", now.Add(-time.Hour), now.Add(time.Hour))
+	tests := map[string]struct {
+		path   string
+		expErr string
+	}{
+		"system CAs": {},
+		"ca": {
+			path: ca,
+		},
+		"missing file": {
+			path:   filepath.Join(dir, "missing.pem"),
+			expErr: "ca cannot be read",
+		},
+		"empty file": {
+			path:   filepath.Join(dir, "empty.pem"),
+			expErr: "contains no PEM encoded certificates",
+		},
+		"no PEM": {
+			path:   filepath.Join(dir, "garbage.pem"),
+			expErr: "contains no PEM encoded certificates",
+		},
+		"key only": {
+			path:   key,
+			expErr: "contains no PEM encoded certificates",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			checkErr(t, validCA(test.path)(), test.expErr)
+		})
+	}
+}
+
+func TestValidSchemaDir(t *testing.T) {
+	withSQL := writeFiles(t, map[string]string{"0001_init.sql": "create table t();", "README.md": ""})
+	withoutSQL := writeFiles(t, map[string]string{"0001_init.txt": "", "README.md": ""})
+	if err := os.Mkdir(filepath.Join(withoutSQL, "0002.sql"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		path   string
+		expErr string
+	}{
+		"sql files": {
+			path: withSQL,
+		},
+		"empty path": {
+			expErr: "path is required when migrations are enabled",
+		},
+		"missing dir": {
+			path:   filepath.Join(withSQL, "missing"),
+			expErr: "schema directory cannot be read",
+		},
+		"no sql files": {
+			path:   withoutSQL,
+			expErr: "contains no .sql migration files",
+		},
+		"empty dir": {
+			path:   t.TempDir(),
+			expErr: "contains no .sql migration files",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			checkErr(t, validSchemaDir(test.path)(), test.expErr)
+		})
+	}
+}
+
+func TestServer_Validate_TLS(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	cert, key := writeCert(t, dir, "server", now.Add(-time.Hour), now.Add(time.Hour))
+	expired, _ := writeCert(t, dir, "expired", now.Add(-2*time.Hour), now.Add(-time.Hour))
+	missing := filepath.Join(dir, "missing.pem")
+	tests := map[string]struct {
+		server Server
+		expErr map[string]string
+	}{
+		"tls disabled": {
+			server: Server{Port: "8080", TLSCertPath: missing},
+		},
+		"valid": {
+			server: Server{Port: "8080", TLSEnabled: true, TLSCertPath: cert, TLSKeyPath: key},
+		},
+		"missing cert isn't checked as a pair": {
+			server: Server{Port: "8080", TLSEnabled: true, TLSCertPath: missing, TLSKeyPath: key},
+			expErr: map[string]string{EnvServerTLSCert: "file cannot be read"},
+		},
+		"missing key isn't checked as a pair": {
+			server: Server{Port: "8080", TLSEnabled: true, TLSCertPath: cert},
+			expErr: map[string]string{EnvServerTLSKey: "path is required when tls is enabled"},
+		},
+		"mismatched pair": {
+			server: Server{Port: "8080", TLSEnabled: true, TLSCertPath: expired, TLSKeyPath: key},
+			expErr: map[string]string{EnvServerTLSCert: "invalid tls cert and key pair"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			errs := test.server.Validate(validator.New())
+			if len(errs) != len(test.expErr) {
+				t.Fatalf("expected errors for %v, got %v", test.expErr, errs)
+			}
+			for key, exp := range test.expErr {
+				msgs := errs[key]
+				if len(msgs) != 1 || !strings.Contains(msgs[0], exp) {
+					t.Fatalf("expected one error for %s containing %q, got %v", key, exp, msgs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package goconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// VaultOption can be supplied to NewVaultResolver.
+type VaultOption func(v *VaultResolver)
+
+// WithVaultHTTPClient sets the http client used to call vault, by
+// default a client with a 10 second timeout is used.
+func WithVaultHTTPClient(c *http.Client) VaultOption {
+	return func(v *VaultResolver) {
+		v.client = c
+	}
+}
+
+// WithVaultNamespace sets the vault enterprise namespace secrets are read from.
+func WithVaultNamespace(namespace string) VaultOption {
+	return func(v *VaultResolver) {
+		v.namespace = namespace
+	}
+}
+
+// VaultResolver is a SecretResolver reading secrets from a HashiCorp Vault
+// KV v2 secrets engine over its http api.
+//
+// References are in the form vault://<mount>/data/<path>#<field>, for example
+// vault://secret/data/payments#dsn reads the dsn field of the payments secret in
+// the secret mount. A version can be requested with vault://secret/data/payments?version=2#dsn.
+//
+// Each secret is read once, so several fields can be read from a secret with one request.
+type VaultResolver struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+	mu        sync.Mutex
+	cache     map[string]map[string]interface{}
+}
+
+// NewVaultResolver returns a VaultResolver calling the vault server at addr,
+// such as https://vault:8200, authenticating with token.
+func NewVaultResolver(addr, token string, opts ...VaultOption) *VaultResolver {
+	v := &VaultResolver{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  map[string]map[string]interface{}{},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// vaultResponse is the response from a KV v2 read.
+type vaultResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// vaultErrors is the body returned by vault on failure.
+type vaultErrors struct {
+	Errors []string `json:"errors"`
+}
+
+// Resolve implements SecretResolver, returning the field of the secret in ref.
+func (v *VaultResolver) Resolve(ref *url.URL) (string, error) {
+	if ref.Fragment == "" {
+		return "", errors.New("vault reference must name a field, such as vault://secret/data/payments#dsn")
+	}
+	path := strings.Trim(ref.Host+ref.Path, "/")
+	if path == "" {
+		return "", errors.New("vault reference must contain a path, such as vault://secret/data/payments#dsn")
+	}
+	if ref.RawQuery != "" {
+		path += "?" + ref.RawQuery
+	}
+	data, err := v.read(path)
+	if err != nil {
+		return "", err
+	}
+	val, ok := data[ref.Fragment]
+	if !ok {
+		return "", fmt.Errorf("field %s not found in vault secret %s", ref.Fragment, path)
+	}
+	return cast.ToStringE(val)
+}
+
+// read returns the data of the secret at path, from the cache if it has already been read.
+func (v *VaultResolver) read(path string) (map[string]interface{}, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if data, ok := v.cache[path]; ok {
+		return data, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, v.addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errs vaultErrors
+		if err := json.Unmarshal(body, &errs); err == nil && len(errs.Errors) > 0 {
+			return nil, fmt.Errorf("vault returned %d reading %s: %s", resp.StatusCode, path, strings.Join(errs.Errors, ", "))
+		}
+		return nil, fmt.Errorf("vault returned %d reading %s", resp.StatusCode, path)
+	}
+	var vr vaultResponse
+	if err := json.Unmarshal(body, &vr); err != nil {
+		return nil, fmt.Errorf("failed to decode vault secret %s: %w", path, err)
+	}
+	if vr.Data.Data == nil {
+		return nil, fmt.Errorf("vault secret %s has no data, is it a kv v2 secret?", path)
+	}
+	v.cache[path] = vr.Data.Data
+	return vr.Data.Data, nil
+}
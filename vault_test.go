@@ -0,0 +1,127 @@
+package goconfig
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+// newVaultServer returns a stand-in for the vault KV v2 api, counting requests.
+func newVaultServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		data := map[string]interface{}{"dsn": "postgres://payments", "port": 5432}
+		switch {
+		case r.URL.Path != "/v1/secret/data/payments":
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {}})
+			return
+		case r.URL.Query().Get("version") == "1":
+			data = map[string]interface{}{"dsn": "postgres://payments-v1"}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 2}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestVaultResolver_Resolve(t *testing.T) {
+	tests := map[string]struct {
+		token  string
+		ref    string
+		exp    string
+		expErr string
+	}{
+		"field": {
+			ref: "vault://secret/data/payments#dsn",
+			exp: "postgres://payments",
+		},
+		"number field": {
+			ref: "vault://secret/data/payments#port",
+			exp: "5432",
+		},
+		"version": {
+			ref: "vault://secret/data/payments?version=1#dsn",
+			exp: "postgres://payments-v1",
+		},
+		"missing field": {
+			ref:    "vault://secret/data/payments#password",
+			expErr: "field password not found in vault secret secret/data/payments",
+		},
+		"missing secret": {
+			ref:    "vault://secret/data/orders#dsn",
+			expErr: "vault returned 404 reading secret/data/orders",
+		},
+		"vault errors": {
+			token:  "bad",
+			ref:    "vault://secret/data/payments#dsn",
+			expErr: "vault returned 403 reading secret/data/payments: permission denied",
+		},
+		"no field": {
+			ref:    "vault://secret/data/payments",
+			expErr: "vault reference must name a field, such as vault://secret/data/payments#dsn",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			srv, _ := newVaultServer(t)
+			token := test.token
+			if token == "" {
+				token = "token"
+			}
+			ref, err := url.Parse(test.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			val, err := NewVaultResolver(srv.URL, token).Resolve(ref)
+			if test.expErr != "" {
+				if err == nil || err.Error() != test.expErr {
+					t.Fatalf("expected error %q, got %v", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if val != test.exp {
+				t.Fatalf("expected %s, got %s", test.exp, val)
+			}
+		})
+	}
+}
+
+func TestVaultResolver_Cache(t *testing.T) {
+	srv, requests := newVaultServer(t)
+	r := NewVaultResolver(srv.URL, "token")
+	for _, ref := range []string{
+		"vault://secret/data/payments#dsn",
+		"vault://secret/data/payments#port",
+		"vault://secret/data/payments#dsn",
+		"vault://secret/data/payments?version=1#dsn",
+	} {
+		u, err := url.Parse(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Resolve(u); err != nil {
+			t.Fatalf("%s: unexpected error: %s", ref, err)
+		}
+	}
+	// one request for the latest version and one for version 1.
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+}
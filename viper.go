@@ -7,134 +7,464 @@ import (
 	"strings"
 	"time"
 
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	validator "github.com/theflyingcodr/govalidator"
 )
 
 // ViperConfig wraps config and implements ConfigurationLoader.
+//
+// Each ViperConfig reads from its own *viper.Viper so multiple loaders
+// can exist in a single process without sharing state.
 type ViperConfig struct {
 	*Config
+	v          *viper.Viper
+	opts       *viperOptions
+	errs       validator.ErrValidation
+	bound      map[string]struct{}
+	envAliases map[string][]string
+	defaults   map[string]interface{}
+	files      []configFile
+	missing    map[string]struct{}
+	required   map[string]struct{}
+	secrets    map[string]struct{}
+	envFiles   map[string]envFile
+	resolved   map[string]string
+	encKey     []byte
+	encKeyErr  error
+	// remoteValues are the values read from the remote provider at startup.
+	remoteValues map[string]string
+	// initErrs are the errors found when the loader was created, or by calls that
+	// aren't repeated by reload.
+	initErrs validator.ErrValidation
+	// loaders load each section again when flags are set, see reload. They are keyed
+	// by section, loaderOrder lists the keys in the order they were first added.
+	loaders     map[string]func()
+	loaderOrder []string
+	flagSet     *pflag.FlagSet
+	flags       map[string]*pflag.Flag
 }
 
 // NewViperConfig will setup and return viper configuration that
 // implements goconfig.ConfigurationLoader.
-func NewViperConfig(appname string) *ViperConfig {
-	viper.SetConfigName("config")
-	viper.SetConfigType("ini")
-	viper.AddConfigPath(fmt.Sprintf("/etc/%s/", appname))
-	viper.AddConfigPath(fmt.Sprintf("$HOME/.%s", appname))
-	viper.AddConfigPath(".")
-
-	if err := viper.ReadInConfig(); err != nil {
-		if errors.Is(err, viper.ConfigFileNotFoundError{}) {
-			// Config file not found
-			viper.Set("debug", false)
-			viper.Set("port", 1323)
-		} else {
-			log.Fatalf("Fatal error config file: %s", err)
-		}
+//
+// A new, isolated, viper instance is created for the loader, use
+// NewViperConfigWithViper if you wish to supply your own.
+//
+// Any errors found reading config files are not returned here, they
+// are collected and returned when LoadE is called.
+func NewViperConfig(appname string, opts ...ViperOption) *ViperConfig {
+	return NewViperConfigWithViper(appname, viper.New(), opts...)
+}
+
+// NewViperConfigWithViper will setup and return viper configuration that
+// implements goconfig.ConfigurationLoader using the viper instance provided.
+//
+// This is useful when you have already setup defaults or overrides on a viper
+// instance, if you rely on the package level viper functions, pass viper.GetViper().
+func NewViperConfigWithViper(appname string, v *viper.Viper, opts ...ViperOption) *ViperConfig {
+	o := defaultViperOptions(appname)
+	for _, opt := range opts {
+		opt(o)
 	}
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	return &ViperConfig{
+	c := &ViperConfig{
 		Config: &Config{
 			httpClients: map[string]HTTPClientConfig{},
+			sections:    map[string]interface{}{},
 		},
+		v:          v,
+		opts:       o,
+		errs:       validator.New(),
+		bound:      map[string]struct{}{},
+		envAliases: map[string][]string{},
+		defaults:   map[string]interface{}{},
+		missing:    map[string]struct{}{},
+		required:   map[string]struct{}{},
+		secrets:    map[string]struct{}{},
+		envFiles:   map[string]envFile{},
+		resolved:   map[string]string{},
+	}
+	c.AddValidator(o.validators...)
+	for _, ss := range builtinSettings() {
+		// secrets are known up front so they are redacted even if the section isn't loaded.
+		c.markSecrets(ss)
+	}
+	for k, val := range o.defaults {
+		c.setDefault(k, val)
 	}
+	v.SetEnvPrefix(o.envPrefix)
+	v.AutomaticEnv()
+	if o.envReplacer != nil {
+		// a nil *strings.Replacer stored in viper's interface would be called.
+		v.SetEnvKeyReplacer(o.envReplacer)
+	}
+	if o.remote != nil {
+		// read first so config files are merged over remote values.
+		c.readRemote()
+	}
+	if o.fileLookup {
+		c.readConfigFiles()
+	}
+	c.initErrs = validator.New()
+	mergeErrs(c.initErrs, c.errs)
+	return c
 }
 
 // WithServer will setup the web server configuration if required.
-func (c *ViperConfig) WithServer() ConfigurationLoader {
+func (c *ViperConfig) WithServer(opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("server", func() { c.WithServer(opts...) })
+	c.setDefaults(serverSettings)
 	c.Server = &Server{
-		Port:         viper.GetString(EnvServerPort),
-		Hostname:     viper.GetString(EnvServerHost),
-		TLSEnabled:   viper.GetBool(EnvServerTLSEnabled),
-		TLSCertPath:  viper.GetString(EnvServerTLSCert),
-		PProfEnabled: viper.GetBool(EnvServerPprofEnabled),
+		Port:         NormalisePort(c.getString(EnvServerPort)),
+		Hostname:     c.getString(EnvServerHost),
+		TLSEnabled:   c.getBool(EnvServerTLSEnabled),
+		TLSCertPath:  c.getString(EnvServerTLSCert),
+		TLSKeyPath:   c.getString(EnvServerTLSKey),
+		PProfEnabled: c.getBool(EnvServerPprofEnabled),
 	}
+	c.checkRequired(serverSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // WithEnvironment sets up the deployment configuration if required.
-func (c *ViperConfig) WithEnvironment(appName string) ConfigurationLoader {
-	viper.SetDefault(EnvEnvironment, "dev")
-	viper.SetDefault(EnvRegion, "test")
-	viper.SetDefault(EnvCommit, "test")
-	viper.SetDefault(EnvVersion, "test")
-	viper.SetDefault(EnvBuildDate, time.Now().UTC())
-
+func (c *ViperConfig) WithEnvironment(appName string, opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("environment", func() { c.WithEnvironment(appName, opts...) })
+	c.setDefaults(deploymentSettings)
 	c.Deployment = &Deployment{
-		Environment: viper.GetString(EnvEnvironment),
-		Region:      viper.GetString(EnvRegion),
-		Version:     viper.GetString(EnvVersion),
-		Commit:      viper.GetString(EnvCommit),
-		BuildDate:   viper.GetTime(EnvBuildDate),
+		Environment: NormaliseEnvironment(c.getString(EnvEnvironment)),
+		Region:      c.getString(EnvRegion),
+		Version:     c.getString(EnvVersion),
+		Commit:      c.getString(EnvCommit),
+		BuildDate:   c.getTime(EnvBuildDate),
 		AppName:     appName,
 	}
+	c.checkRequired(deploymentSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // WithLog sets up logger config from environment variables.
-func (c *ViperConfig) WithLog() ConfigurationLoader {
-	c.Logging = &Logging{Level: viper.GetString(EnvLogLevel)}
+func (c *ViperConfig) WithLog(opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("log", func() { c.WithLog(opts...) })
+	c.setDefaults(loggingSettings)
+	c.Logging = &Logging{Level: c.getString(EnvLogLevel)}
+	c.checkRequired(loggingSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // WithDb sets up and returns database configuration.
-func (c *ViperConfig) WithDb() ConfigurationLoader {
+func (c *ViperConfig) WithDb(opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("db", func() { c.WithDb(opts...) })
+	c.setDefaults(dbSettings)
 	c.Db = &Db{
-		Type:       DbType(viper.GetString(EnvDb)),
-		Dsn:        viper.GetString(EnvDbDsn),
-		SchemaPath: viper.GetString(EnvDbSchema),
-		Migrate:    viper.GetBool(EnvDbMigrate),
+		Type:       DbType(c.getString(EnvDb)),
+		Dsn:        c.getString(EnvDbDsn),
+		SchemaPath: c.getString(EnvDbSchema),
+		Migrate:    c.getBool(EnvDbMigrate),
 	}
+	c.checkRequired(dbSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // WithRedis will include redis config.
-func (c *ViperConfig) WithRedis() ConfigurationLoader {
-	if !viper.IsSet(EnvRedisDb) {
-		viper.SetDefault(EnvRedisDb, 0)
-	}
+func (c *ViperConfig) WithRedis(opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("redis", func() { c.WithRedis(opts...) })
+	c.setDefaults(redisSettings)
 	c.Redis = &Redis{
-		Address:  viper.GetString(EnvRedisAddress),
-		Password: viper.GetString(EnvRedisPassword),
-		Db:       viper.GetUint(EnvRedisDb),
+		Address:  c.getString(EnvRedisAddress),
+		Password: c.getString(EnvRedisPassword),
+		Db:       c.getUint(EnvRedisDb),
 	}
+	c.checkRequired(redisSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // WithHTTPClient will setup a custom http client referenced by name.
-func (c *ViperConfig) WithHTTPClient(name string) ConfigurationLoader {
+func (c *ViperConfig) WithHTTPClient(name string, opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("http-client:"+name, func() { c.WithHTTPClient(name, opts...) })
+	settings := httpClientSettings(name)
+	c.setDefaults(settings)
 	c.httpClients[name] = HTTPClientConfig{
-		Host:       viper.GetString(fmt.Sprintf(EnvHTTPClientHost, name)),
-		Port:       viper.GetString(fmt.Sprintf(EnvHTTPClientPort, name)),
-		TLSEnabled: viper.GetBool(fmt.Sprintf(EnvHTTPClientTLSEnabled, name)),
-		TLSCert:    viper.GetBool(fmt.Sprintf(EnvHTTPClientTLSCert, name)),
-		Timeout:    time.Second * time.Duration(viper.GetInt(fmt.Sprintf(EnvHTTPClientTimeout, name))),
+		Name:       name,
+		Host:       c.getString(fmt.Sprintf(EnvHTTPClientHost, name)),
+		Port:       NormalisePort(c.getString(fmt.Sprintf(EnvHTTPClientPort, name))),
+		TLSEnabled: c.getBool(fmt.Sprintf(EnvHTTPClientTLSEnabled, name)),
+		TLSCert:    c.getBool(fmt.Sprintf(EnvHTTPClientTLSCert, name)),
+		TLSCAPath:  c.getString(fmt.Sprintf(EnvHTTPClientTLSCA, name)),
+		Timeout:    c.getTimeout(fmt.Sprintf(EnvHTTPClientTimeout, name)),
 	}
+	c.checkRequired(settings, formatKeys(newSectionOptions(opts).required, name))
 	return c
 }
 
 // WithSwagger will setup and return swagger configuration.
-func (c *ViperConfig) WithSwagger() ConfigurationLoader {
+func (c *ViperConfig) WithSwagger(opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("swagger", func() { c.WithSwagger(opts...) })
+	c.setDefaults(swaggerSettings)
 	c.Swagger = &Swagger{
-		Host:    viper.GetString(EnvSwaggerHost),
-		Enabled: viper.GetBool(EnvSwaggerEnabled),
+		Host:    c.getString(EnvSwaggerHost),
+		Enabled: c.getBool(EnvSwaggerEnabled),
 	}
+	c.checkRequired(swaggerSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // WithInstrumentation will read instrumentation environment vars.
-func (c *ViperConfig) WithInstrumentation() ConfigurationLoader {
+func (c *ViperConfig) WithInstrumentation(opts ...SectionOption) ConfigurationLoader {
+	c.addLoader("instrumentation", func() { c.WithInstrumentation(opts...) })
+	c.setDefaults(instrumentationSettings)
 	c.Instrumentation = &Instrumentation{
-		MetricsEnabled: viper.GetBool(EnvMetricsEnabled),
-		TracingEnabled: viper.GetBool(EnvTracingEnabled),
+		MetricsEnabled: c.getBool(EnvMetricsEnabled),
+		TracingEnabled: c.getBool(EnvTracingEnabled),
 	}
+	c.checkRequired(instrumentationSettings, newSectionOptions(opts).required)
 	return c
 }
 
 // Load will finish setup and return configuration. This should
 // always be the last call.
+//
+// The configuration isn't validated, use LoadE to validate it and handle
+// any errors yourself. As before LoadE was added, the process will exit
+// if a config file can't be read.
 func (c *ViperConfig) Load() *Config {
+	if c.flagsChanged() {
+		c.reload()
+	}
+	if msgs := c.errs[errKeyConfigFile]; len(msgs) > 0 {
+		log.Fatalf("Fatal error config file: %s", strings.Join(msgs, ", "))
+	}
 	return c.Config
 }
+
+// LoadE will finish setup, validate and return configuration. This should
+// always be the last call.
+//
+// Every problem found while reading config files, loading each section
+// and validating the result is returned as a single validator.ErrValidation
+// keyed by the config key at fault. Each message names the env var, config
+// key and source of the value, with secret values redacted.
+//
+// If strict mode is enabled, unknown keys are also reported, see WithStrict.
+// If any flags returned by FlagSet have been set, each section is loaded again
+// so their values are used.
+func (c *ViperConfig) LoadE() (*Config, error) {
+	if c.flagsChanged() {
+		c.reload()
+	}
+	return c.validate()
+}
+
+// Reload will load each section, http client and custom section again then validate
+// and return the configuration as LoadE does. Changes made since they were loaded,
+// such as remote values applied by WatchRemote, are used and values that were
+// missing are read again. Custom sections are loaded into the same target.
+//
+// A new Config is returned, any Config returned previously isn't changed.
+func (c *ViperConfig) Reload() (*Config, error) {
+	c.reload()
+	return c.validate()
+}
+
+// validate will validate the loaded configuration, returning it along with
+// any errors found while it was loaded.
+func (c *ViperConfig) validate() (*Config, error) {
+	errs := validator.New()
+	mergeErrs(errs, c.errs)
+	c.checkUnknown(errs)
+	vErrs := validator.New()
+	mergeErrs(vErrs, c.Config.Validate())
+	for k := range c.missing {
+		// missing and unusable values have already been reported.
+		delete(vErrs, k)
+	}
+	mergeErrs(errs, vErrs)
+	if err := c.annotate(errs).Err(); err != nil {
+		return nil, err
+	}
+	return c.Config, nil
+}
+
+// errKeyConfigFile is the key used to report config file errors.
+const errKeyConfigFile = "config.file"
+
+// addErr will record err against key, if err is nil it is ignored.
+func (c *ViperConfig) addErr(key string, err error) {
+	if err == nil {
+		return
+	}
+	c.errs[key] = append(c.errs[key], err.Error())
+}
+
+// get will return the value for key after binding its environment variables.
+func (c *ViperConfig) get(key string) interface{} {
+	c.bindEnv(key)
+	c.resolve(key)
+	return c.lookup(key)
+}
+
+// lookup returns the value in use for key, resolved secrets, flags and values
+// read using a *_FILE env var are returned ahead of viper.
+func (c *ViperConfig) lookup(key string) interface{} {
+	if val, ok := c.resolved[key]; ok {
+		return val
+	}
+	if val, ok := c.flagValue(key); ok {
+		return val
+	}
+	if f, ok := c.envFiles[key]; ok {
+		return f.value
+	}
+	return c.v.Get(key)
+}
+
+// getString returns the value of key as a string.
+func (c *ViperConfig) getString(key string) string {
+	s, err := cast.ToStringE(c.get(key))
+	c.addErr(key, err)
+	return s
+}
+
+// getBool returns the value of key as a bool, recording an error
+// if the value is not a valid bool.
+func (c *ViperConfig) getBool(key string) bool {
+	val := c.get(key)
+	if isEmpty(val) {
+		return false
+	}
+	b, err := cast.ToBoolE(val)
+	c.addErr(key, err)
+	return b
+}
+
+// getInt64 returns the value of key as an int64, recording an error
+// if the value is not a valid int64.
+func (c *ViperConfig) getInt64(key string) int64 {
+	val := c.get(key)
+	if isEmpty(val) {
+		return 0
+	}
+	i, err := cast.ToInt64E(val)
+	c.addErr(key, err)
+	return i
+}
+
+// getUint returns the value of key as a uint, recording an error
+// if the value is not a valid uint.
+func (c *ViperConfig) getUint(key string) uint {
+	val := c.get(key)
+	if isEmpty(val) {
+		return 0
+	}
+	i, err := cast.ToUintE(val)
+	c.addErr(key, err)
+	return i
+}
+
+// getUint64 returns the value of key as a uint64, recording an error
+// if the value is not a valid uint64.
+func (c *ViperConfig) getUint64(key string) uint64 {
+	val := c.get(key)
+	if isEmpty(val) {
+		return 0
+	}
+	i, err := cast.ToUint64E(val)
+	c.addErr(key, err)
+	return i
+}
+
+// getFloat64 returns the value of key as a float64, recording an error
+// if the value is not a valid float64.
+func (c *ViperConfig) getFloat64(key string) float64 {
+	val := c.get(key)
+	if isEmpty(val) {
+		return 0
+	}
+	f, err := cast.ToFloat64E(val)
+	c.addErr(key, err)
+	return f
+}
+
+// getDuration returns the value of key as a time.Duration, recording an error
+// if the value is not a valid duration such as 30s.
+func (c *ViperConfig) getDuration(key string) time.Duration {
+	val := c.get(key)
+	if isEmpty(val) {
+		return 0
+	}
+	d, err := cast.ToDurationE(val)
+	c.addErr(key, err)
+	return d
+}
+
+// getTimeout returns the value of key as a time.Duration, plain numbers
+// are treated as seconds, otherwise a duration such as 500ms is expected.
+func (c *ViperConfig) getTimeout(key string) time.Duration {
+	val := c.get(key)
+	if isEmpty(val) {
+		return 0
+	}
+	if _, ok := val.(time.Duration); ok {
+		return c.getDuration(key)
+	}
+	if i, err := cast.ToInt64E(val); err == nil {
+		return time.Second * time.Duration(i)
+	}
+	return c.getDuration(key)
+}
+
+// getStringSlice returns the value of key as a string slice, strings
+// are split on commas so a,b,c can be supplied as an env var.
+func (c *ViperConfig) getStringSlice(key string) []string {
+	val := c.get(key)
+	if isEmpty(val) {
+		return nil
+	}
+	if s, ok := val.(string); ok {
+		ss := strings.Split(s, ",")
+		for i := range ss {
+			ss[i] = strings.TrimSpace(ss[i])
+		}
+		return ss
+	}
+	ss, err := cast.ToStringSliceE(val)
+	c.addErr(key, err)
+	return ss
+}
+
+// getTime returns the value of key as a time.Time, recording an error
+// if the value is not a valid time.
+func (c *ViperConfig) getTime(key string) time.Time {
+	val := c.get(key)
+	if isEmpty(val) {
+		return time.Time{}
+	}
+	t, err := cast.ToTimeE(val)
+	c.addErr(key, err)
+	return t
+}
+
+// isEmpty returns true if a value has not been set or is a blank string.
+func isEmpty(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	s, ok := val.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
+
+// mergeErrs will add err to dst, if err is a validator.ErrValidation
+// each field is merged, otherwise it is recorded against the config key.
+func mergeErrs(dst validator.ErrValidation, err error) {
+	if err == nil {
+		return
+	}
+	var errs validator.ErrValidation
+	if !errors.As(err, &errs) {
+		dst["config"] = append(dst["config"], err.Error())
+		return
+	}
+	for k, vv := range errs {
+		dst[k] = append(dst[k], vv...)
+	}
+}
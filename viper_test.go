@@ -0,0 +1,141 @@
+package goconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewViperConfig_Isolated(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		opts     []ViperOption
+		port     Port
+		logLevel string
+		redis    string
+	}{
+		"defaults": {
+			port:     "8080",
+			logLevel: LogInfo,
+			redis:    "localhost:6379",
+		},
+		"overridden defaults": {
+			opts: []ViperOption{WithDefaults(map[string]interface{}{
+				EnvServerPort:   "9000",
+				EnvLogLevel:     LogDebug,
+				EnvRedisAddress: "redis:6379",
+			})},
+			port:     "9000",
+			logLevel: LogDebug,
+			redis:    "redis:6379",
+		},
+		"prefixed with other defaults": {
+			opts: []ViperOption{
+				WithEnvPrefix("isolated"),
+				WithDefaults(map[string]interface{}{EnvServerPort: 9100, EnvLogLevel: LogError}),
+			},
+			port:     "9100",
+			logLevel: LogError,
+			redis:    "localhost:6379",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			// load repeatedly so loaders created by other subtests run alongside.
+			for i := 0; i < 20; i++ {
+				opts := append([]ViperOption{WithoutFileLookup()}, test.opts...)
+				cfg, err := NewViperConfig("isolated", opts...).
+					WithServer().WithLog().WithRedis().LoadE()
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if cfg.Server.Port != test.port {
+					t.Fatalf("expected port %s, got %s", test.port, cfg.Server.Port)
+				}
+				if cfg.Logging.Level != test.logLevel {
+					t.Fatalf("expected log level %s, got %s", test.logLevel, cfg.Logging.Level)
+				}
+				if cfg.Redis.Address != test.redis {
+					t.Fatalf("expected redis address %s, got %s", test.redis, cfg.Redis.Address)
+				}
+			}
+		})
+	}
+}
+
+func TestNewViperConfigWithViper_Isolated(t *testing.T) {
+	t.Parallel()
+	for i := 0; i < 4; i++ {
+		port := fmt.Sprintf("70%02d", i)
+		t.Run(port, func(t *testing.T) {
+			t.Parallel()
+			v := viper.New()
+			v.Set(EnvServerPort, port)
+			cfg, err := NewViperConfigWithViper("isolated", v, WithoutFileLookup()).WithServer().LoadE()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cfg.Server.Port != Port(port) {
+				t.Fatalf("expected port %s, got %s", port, cfg.Server.Port)
+			}
+			if viper.IsSet(EnvServerPort) {
+				t.Fatal("package level viper should not be modified")
+			}
+		})
+	}
+}
+
+func TestLoad_DoesNotValidate(t *testing.T) {
+	t.Setenv("SERVER_PORT", "70000")
+	loader := NewViperConfig("load", WithoutFileLookup())
+	cfg := loader.WithServer().WithDb().WithHTTPClient("payments").Load()
+	if cfg.Server.Port != "70000" {
+		t.Fatalf("expected port 70000, got %s", cfg.Server.Port)
+	}
+	if cfg.CustomHTTPClient("payments") == nil {
+		t.Fatal("expected the payments client to be loaded")
+	}
+	if _, err := loader.LoadE(); err == nil {
+		t.Fatal("expected LoadE to validate the config")
+	}
+}
+
+func TestReload_Loaders(t *testing.T) {
+	type payments struct {
+		Host string `config:"host" default:"localhost"`
+	}
+	t.Setenv("A_CLIENT_HOST", "a.example.com")
+	t.Setenv("B_CLIENT_HOST", "b.example.com")
+	var p, other payments
+	loader := NewViperConfig("reload", WithoutFileLookup())
+	loader.WithServer().WithHTTPClient("a").WithServer().WithSection("payments", &p).
+		WithHTTPClient("b").WithHTTPClient("a").WithServer()
+	exp := []string{"server", "http-client:a", "section:payments", "http-client:b"}
+	for i := 0; i < 3; i++ {
+		if _, err := loader.Reload(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(loader.loaderOrder, exp) || len(loader.loaders) != len(exp) {
+			t.Fatalf("expected loaders %v, got %v", exp, loader.loaderOrder)
+		}
+	}
+
+	loader.WithSection("payments", &other)
+	for i := 0; i < 2; i++ {
+		_, err := loader.Reload()
+		if err == nil || !strings.Contains(err.Error(), "section payments has already been loaded") {
+			t.Fatalf("expected the duplicate section error to be kept, got %v", err)
+		}
+		if !reflect.DeepEqual(loader.loaderOrder, exp) {
+			t.Fatalf("expected loaders %v, got %v", exp, loader.loaderOrder)
+		}
+	}
+	if p.Host != "localhost" || other.Host != "" {
+		t.Fatalf("expected only the first section target to be loaded, got %+v and %+v", p, other)
+	}
+}